@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTimerFirings bounds a run so recurring deadlines, such as automatic renewals, end.
+const maxTimerFirings = 1000
+
+var (
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrTimerLoop       = fmt.Errorf("deadlines fired more than %d times", maxTimerFirings)
+)
+
+// durationPart matches one component of a duration such as 1y, 2w, 30d or 12h.
+var durationPart = regexp.MustCompile(`^(\d+(?:\.\d+)?)(y|w|d|h|ms|m|s)`)
+
+// deadline is a timer declared by a timeline or test fixture. Once the contract has been in
+// State for After, a CloudEvent of type Event is delivered.
+type deadline struct {
+	Name  string                 `yaml:"name,omitempty" json:"name,omitempty"`
+	State string                 `yaml:"state" json:"state"`
+	After string                 `yaml:"after" json:"after"`
+	Event string                 `yaml:"event" json:"event"`
+	Data  map[string]interface{} `yaml:"data,omitempty" json:"data,omitempty"`
+
+	after time.Duration
+}
+
+func (d deadline) String() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return fmt.Sprintf("%s after %s in %s", d.Event, d.After, d.State)
+}
+
+// armedTimer is a deadline that will fire at Due unless the contract leaves its state first.
+type armedTimer struct {
+	Deadline int
+	Due      time.Time
+}
+
+// timedStep is a step of the machine at a point on the virtual clock.
+type timedStep struct {
+	At time.Time `json:"at"`
+	// Deadline names the deadline that emitted the event, if the event was synthetic.
+	Deadline string `json:"deadline,omitempty"`
+	step
+}
+
+// virtualClock drives a machine through time. Entering a state arms its deadlines and leaving
+// it disarms them; re-entering a state, even through a self-transition, arms them again.
+type virtualClock struct {
+	m         *machine
+	start     time.Time
+	now       time.Time
+	deadlines []deadline
+	armed     []armedTimer
+	firings   int
+}
+
+func newVirtualClock(m *machine, start time.Time, deadlines []deadline) (*virtualClock, error) {
+	vc := &virtualClock{m: m, start: start, now: start}
+	for _, d := range deadlines {
+		after, err := parseDuration(d.After)
+		if err != nil {
+			return nil, fmt.Errorf("deadline %s: %w", d, err)
+		}
+		if after <= 0 {
+			return nil, fmt.Errorf("deadline %s: %w: must be greater than zero", d, ErrInvalidDuration)
+		}
+		if d.Event == "" {
+			return nil, fmt.Errorf("deadline %s: no event", d)
+		}
+		if _, ok := m.states[d.State]; !ok {
+			return nil, fmt.Errorf("deadline %s: %w: %q", d, ErrUndefinedState, d.State)
+		}
+		d.after = after
+		vc.deadlines = append(vc.deadlines, d)
+	}
+	return vc, nil
+}
+
+// begin enters the initial state, or the given state when it is not empty, and arms its
+// deadlines.
+func (vc *virtualClock) begin(state string) (*timedStep, error) {
+	if state != "" {
+		if err := vc.m.enter(state); err != nil {
+			return nil, err
+		}
+		vc.arm()
+		return nil, nil
+	}
+	s := vc.m.start()
+	vc.arm()
+	return &timedStep{At: vc.now, step: s}, nil
+}
+
+func (vc *virtualClock) arm() {
+	vc.armed = nil
+	for i, d := range vc.deadlines {
+		if d.State == vc.m.current {
+			vc.armed = append(vc.armed, armedTimer{Deadline: i, Due: vc.now.Add(d.after)})
+		}
+	}
+	sort.SliceStable(vc.armed, func(i, j int) bool { return vc.armed[i].Due.Before(vc.armed[j].Due) })
+}
+
+// advanceTo moves the clock forward to t, firing every deadline that falls due on the way.
+func (vc *virtualClock) advanceTo(t time.Time) ([]timedStep, error) {
+	var steps []timedStep
+	for len(vc.armed) > 0 && !vc.armed[0].Due.After(t) {
+		if vc.firings >= maxTimerFirings {
+			return steps, ErrTimerLoop
+		}
+		vc.firings++
+		timer := vc.armed[0]
+		vc.armed = vc.armed[1:]
+		vc.now = timer.Due
+		d := vc.deadlines[timer.Deadline]
+		e := cloudEvent{
+			SpecVersion: "1.0",
+			ID:          fmt.Sprintf("deadline-%d", vc.firings),
+			Source:      "contract-clock",
+			Type:        d.Event,
+		}
+		if d.Data != nil {
+			data, err := json.Marshal(d.Data)
+			if err != nil {
+				return steps, fmt.Errorf("deadline %s: %w", d, err)
+			}
+			e.Data = data
+		}
+		s, err := vc.deliver(e)
+		if err != nil {
+			return steps, err
+		}
+		s.Deadline = d.String()
+		steps = append(steps, s)
+	}
+	if t.After(vc.now) {
+		vc.now = t
+	}
+	return steps, nil
+}
+
+// deliver fires an event at the current time.
+func (vc *virtualClock) deliver(e cloudEvent) (timedStep, error) {
+	e.Time = vc.now.UTC().Format(time.RFC3339)
+	s, err := vc.m.fire(e)
+	if err != nil {
+		return timedStep{}, err
+	}
+	if s.Fired {
+		vc.arm()
+	}
+	return timedStep{At: vc.now, step: s}, nil
+}
+
+// at resolves a point in time written as an RFC 3339 timestamp or as an offset from the start
+// of the clock, e.g. 10d.
+func (vc *virtualClock) at(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := parseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return vc.start.Add(d), nil
+}
+
+// parseDuration parses durations with calendar units in addition to those of
+// time.ParseDuration: 1y is 365 days, 1w is 7 days and 1d is 24 hours, e.g. 1d12h.
+func parseDuration(s string) (time.Duration, error) {
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidDuration)
+	}
+	units := map[string]time.Duration{
+		"y":  365 * 24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"d":  24 * time.Hour,
+		"h":  time.Hour,
+		"m":  time.Minute,
+		"s":  time.Second,
+		"ms": time.Millisecond,
+	}
+	var total time.Duration
+	for rest != "" {
+		m := durationPart.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		total += time.Duration(n * float64(units[m[2]]))
+		rest = rest[len(m[0]):]
+	}
+	return total, nil
+}
+
+// formatOffset renders the time since the start of the clock in days and hours, e.g. +30d.
+func formatOffset(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	rest := d - time.Duration(days)*24*time.Hour
+	switch {
+	case rest == 0:
+		return fmt.Sprintf("+%dd", days)
+	case days == 0:
+		return "+" + rest.String()
+	}
+	return fmt.Sprintf("+%dd%s", days, rest)
+}
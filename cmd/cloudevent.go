@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/decombine/contract/pkg/condition"
+)
+
+var ErrInvalidEvent = errors.New("invalid CloudEvent")
+
+// cloudEvent is a CloudEvent in the JSON structured content mode.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion,omitempty"`
+	ID              string          `json:"id,omitempty"`
+	Source          string          `json:"source,omitempty"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	// Line is the line of the events file on which the event starts.
+	Line int `json:"-"`
+}
+
+// document returns the event as a generic document, with data decoded, so conditions can
+// address attributes and data alike.
+func (e cloudEvent) document() interface{} {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	doc, _ := condition.Decode(raw)
+	return doc
+}
+
+// readEventsFile reads CloudEvents from a file, or from stdin when path is "-".
+func readEventsFile(path string, stdin io.Reader) ([]cloudEvent, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = readStdin(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseEvents(data)
+}
+
+// parseEvents decodes a JSON array of CloudEvents, a single event or a stream of events
+// such as NDJSON.
+func parseEvents(data []byte) ([]cloudEvent, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var events []cloudEvent
+	if trimmed[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		for i, r := range raw {
+			var e cloudEvent
+			if err := json.Unmarshal(r, &e); err != nil {
+				return nil, fmt.Errorf("%w: event %d: %v", ErrInvalidEvent, i+1, err)
+			}
+			events = append(events, e)
+		}
+	} else {
+		for _, doc := range splitJSONStream(data) {
+			var e cloudEvent
+			if err := json.Unmarshal(doc.Data, &e); err != nil {
+				return nil, fmt.Errorf("%w on line %d: %v", ErrInvalidEvent, doc.Line, err)
+			}
+			e.Line = doc.Line
+			events = append(events, e)
+		}
+	}
+
+	for i, e := range events {
+		if e.Type == "" {
+			if e.Line > 0 {
+				return nil, fmt.Errorf("%w on line %d: missing type", ErrInvalidEvent, e.Line)
+			}
+			return nil, fmt.Errorf("%w: event %d: missing type", ErrInvalidEvent, i+1)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+var ErrBreakingChanges = errors.New("the amendment has unapproved breaking changes")
+
+const changeEventRemoved changeKind = "event-removed"
+
+// semverPattern matches versions such as v1.2.3 and 1.2.3-rc.1.
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:[-+].*)?$`)
+
+// versionBumps are the semantic version bumps, from smallest to largest.
+var versionBumps = []string{"none", "patch", "minor", "major"}
+
+func init() {
+	rootCmd.AddCommand(compatCmd)
+	compatCmd.Flags().StringArray("approve", []string{}, "Approve a breaking change by its ID, e.g. state-removed:Expired")
+	compatCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json")
+}
+
+var compatCmd = &cobra.Command{
+	Use:   "compat OLD NEW",
+	Short: "Check an amendment of a Smart Legal Contract for breaking changes",
+	Long: `Compare two versions of a Smart Legal Contract and classify each change as breaking or compatible for
+instances of the contract that are already running.
+
+Breaking changes can strand live instances or ignore events that counterparties emit:
+
+  state-removed          instances in the state cannot continue
+  state-renamed          instances recorded in the old state cannot continue
+  event-removed          no transition handles an event type any more
+  transition-removed     instances in a state no longer respond to an event
+  transition-event       a transition is triggered by a different event
+  transition-target      a transition leads to a different state
+  transition-conditions  a transition has new or changed conditions
+  transition-added       a new transition comes first on an event and takes it from an existing one
+
+Other added states and transitions, looser conditions, a new initial state and changed actions are compatible.
+compat suggests a semantic version for the amendment and exits non-zero when a breaking change has not been
+approved with --approve. Each version is a file, a URL or a Git revision and path, as for contract diff.`,
+	Example: `  contract compat main:contract.yaml contract.yaml
+  contract compat v1.yaml v2.yaml --approve state-removed:Expired`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		approve, _ := cmd.Flags().GetStringArray("approve")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+		a, err := loadContractVersion(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := loadContractVersion(args[1])
+		if err != nil {
+			return err
+		}
+
+		r := checkCompatibility(a, b, approve)
+		r.Old, r.New = args[0], args[1]
+		if output == "json" {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		} else {
+			printCompatReport(cmd.OutOrStdout(), r)
+		}
+		if r.Unapproved > 0 {
+			return ErrBreakingChanges
+		}
+		return nil
+	},
+}
+
+// compatFinding classifies a change between two versions of a contract.
+type compatFinding struct {
+	ID       string         `json:"id"`
+	Breaking bool           `json:"breaking"`
+	Approved bool           `json:"approved,omitempty"`
+	Bump     string         `json:"bump"`
+	Change   contractChange `json:"change"`
+	Impact   string         `json:"impact,omitempty"`
+}
+
+type compatReport struct {
+	Old              string          `json:"old"`
+	New              string          `json:"new"`
+	Findings         []compatFinding `json:"findings"`
+	Breaking         int             `json:"breaking"`
+	Unapproved       int             `json:"unapproved"`
+	Bump             string          `json:"bump"`
+	OldVersion       string          `json:"oldVersion"`
+	NewVersion       string          `json:"newVersion"`
+	SuggestedVersion string          `json:"suggestedVersion,omitempty"`
+	// DeclaredBump is the bump from the old to the new version field, if both are semantic
+	// versions.
+	DeclaredBump string `json:"declaredBump,omitempty"`
+}
+
+// checkCompatibility classifies the changes between two versions of a contract.
+func checkCompatibility(a, b *slc.Contract, approve []string) compatReport {
+	approved := make(map[string]bool, len(approve))
+	for _, id := range approve {
+		approved[id] = true
+	}
+	r := compatReport{Findings: []compatFinding{}, Bump: "none", OldVersion: a.Version, NewVersion: b.Version}
+	add := func(f compatFinding) {
+		if f.Breaking {
+			r.Breaking++
+			f.Approved = approved[f.ID]
+			if !f.Approved {
+				r.Unapproved++
+			}
+		}
+		if bumpRank(f.Bump) > bumpRank(r.Bump) {
+			r.Bump = f.Bump
+		}
+		r.Findings = append(r.Findings, f)
+	}
+
+	for _, c := range diffContracts(a, b) {
+		if c.Kind == changeMetadata && c.Field == "version" {
+			continue
+		}
+		add(classifyChange(c))
+	}
+	handled := func(c *slc.Contract) map[string]bool {
+		events := make(map[string]bool)
+		for _, s := range c.State.States {
+			for _, t := range s.Transitions {
+				events[t.On] = true
+			}
+		}
+		return events
+	}
+	before, after := handled(a), handled(b)
+	for _, e := range sortedKeys(before) {
+		if !after[e] {
+			add(compatFinding{
+				ID: string(changeEventRemoved) + ":" + e, Breaking: true, Bump: "major",
+				Change: contractChange{Kind: changeEventRemoved, Old: e, Message: fmt.Sprintf("event %s is no longer handled", e)},
+				Impact: "counterparties that emit it are ignored",
+			})
+		}
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return r.Findings[i].Breaking && !r.Findings[j].Breaking
+	})
+	if m := semverPattern.FindStringSubmatch(a.Version); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		patch, _ := strconv.Atoi(m[3])
+		prefix := ""
+		if strings.HasPrefix(a.Version, "v") {
+			prefix = "v"
+		}
+		switch r.Bump {
+		case "major":
+			r.SuggestedVersion = fmt.Sprintf("%s%d.0.0", prefix, major+1)
+		case "minor":
+			r.SuggestedVersion = fmt.Sprintf("%s%d.%d.0", prefix, major, minor+1)
+		case "patch":
+			r.SuggestedVersion = fmt.Sprintf("%s%d.%d.%d", prefix, major, minor, patch+1)
+		}
+		r.DeclaredBump = declaredBump(m, semverPattern.FindStringSubmatch(b.Version))
+	}
+	return r
+}
+
+// classifyChange decides whether a change is breaking for running instances and which
+// version bump it needs.
+func classifyChange(c contractChange) compatFinding {
+	f := compatFinding{ID: string(c.Kind) + ":" + c.State, Change: c, Bump: "minor"}
+	if c.Transition != "" {
+		f.ID += "/" + c.Transition
+	}
+	switch c.Kind {
+	case changeStateRemoved:
+		f.Breaking, f.Impact = true, fmt.Sprintf("instances in %q would be stranded", c.State)
+	case changeStateRenamed:
+		f.Breaking, f.Impact = true, fmt.Sprintf("instances recorded in %q would be stranded", c.Old)
+	case changeTransitionRemoved:
+		f.Breaking, f.Impact = true, fmt.Sprintf("instances in %q no longer respond to %s", c.State, c.Old)
+	case changeTransitionAdded:
+		if c.shadowed != "" {
+			f.Breaking, f.Impact = true, fmt.Sprintf("%s events in %q would no longer reach transition %q", c.New, c.State, c.shadowed)
+		}
+	case changeTransitionEvent:
+		f.Breaking, f.Impact = true, fmt.Sprintf("counterparties that emit %s can no longer move instances in %q", c.Old, c.State)
+	case changeTransitionTarget:
+		f.Breaking, f.Impact = true, fmt.Sprintf("instances in %q would move to %q instead", c.State, c.New)
+	case changeConditions:
+		// Removing conditions only lets more events through.
+		if !guardContains(c.Old, c.New) {
+			f.Breaking, f.Impact = true, "events that moved instances before may no longer do so"
+		}
+	case changeAction:
+		f.ID += "/" + c.Field
+	case changeMetadata:
+		f.ID, f.Bump = string(c.Kind)+":"+c.Field, "patch"
+	case changeInitial:
+		f.ID = string(c.Kind)
+	}
+	if f.Breaking {
+		f.Bump = "major"
+	}
+	return f
+}
+
+// guardContains reports whether every condition of the guard inner is also in outer.
+func guardContains(outer, inner string) bool {
+	if inner == "" {
+		return true
+	}
+	conditions := make(map[string]bool)
+	for _, c := range splitGuard(outer) {
+		conditions[c] = true
+	}
+	for _, c := range splitGuard(inner) {
+		if !conditions[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitGuard(guard string) []string {
+	if guard == "" {
+		return nil
+	}
+	return strings.Split(strings.Trim(guard, "[]"), " and ")
+}
+
+func bumpRank(bump string) int {
+	for i, b := range versionBumps {
+		if b == bump {
+			return i
+		}
+	}
+	return 0
+}
+
+// declaredBump returns the bump between two parsed semantic versions, or "" when the new
+// version is missing or not greater.
+func declaredBump(before, after []string) string {
+	if after == nil {
+		return ""
+	}
+	for i, bump := range []string{"major", "minor", "patch"} {
+		o, _ := strconv.Atoi(before[i+1])
+		n, _ := strconv.Atoi(after[i+1])
+		switch {
+		case n > o:
+			return bump
+		case n < o:
+			return ""
+		}
+	}
+	return "none"
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printCompatReport(w io.Writer, r compatReport) {
+	fmt.Fprintf(w, "%s %s %s %s\n\n", style.Render("Compatibility of"), r.Old, helpStyle("─▶"), r.New)
+	if len(r.Findings) == 0 {
+		fmt.Fprintf(w, "%s no semantic changes\n", successStyle.Render("✓"))
+		return
+	}
+	for _, f := range r.Findings {
+		label, sev := "compatible", severityInfo
+		switch {
+		case f.Breaking && f.Approved:
+			label, sev = "breaking (approved)", severityWarning
+		case f.Breaking:
+			label, sev = "breaking", severityError
+		}
+		fmt.Fprintf(w, "%s[%s]: %s\n", severityStyle(sev).Render(label), f.ID, f.Change.Message)
+		if f.Impact != "" {
+			fmt.Fprintf(w, "       %s %s\n", gutterStyle.Render("impact:"), f.Impact)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if r.SuggestedVersion != "" {
+		fmt.Fprintf(w, "Suggested version: %s (%s bump from %s)\n", style.Render(r.SuggestedVersion), r.Bump, r.OldVersion)
+	} else {
+		fmt.Fprintf(w, "Suggested bump: %s\n", style.Render(r.Bump))
+	}
+	if r.DeclaredBump != "" && bumpRank(r.DeclaredBump) < bumpRank(r.Bump) {
+		fmt.Fprintf(w, "%s the new version %s is a %s bump, but the changes need a %s bump\n", warnStyle.Render("warning:"), r.NewVersion, r.DeclaredBump, r.Bump)
+	}
+	switch {
+	case r.Unapproved > 0:
+		fmt.Fprintf(w, "%s %d breaking change(s), %d not approved\n", ErrStyle.Render("✗"), r.Breaking, r.Unapproved)
+	case r.Breaking > 0:
+		fmt.Fprintf(w, "%s %d breaking change(s), all approved\n", warnStyle.Render("!"), r.Breaking)
+	default:
+		fmt.Fprintf(w, "%s compatible with running instances\n", successStyle.Render("✓"))
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func compatContract(edit func(c *slc.Contract)) *slc.Contract {
+	c := &slc.Contract{Name: "Test", Version: "v1.2.3", State: slc.StateConfiguration{Initial: "Draft", States: []slc.State{
+		{Name: "Draft", Transitions: []slc.Transition{
+			{Name: "Signing", To: "Signed", On: "sign", Conditions: []slc.Condition{{Name: "data.validated", Value: "true"}}},
+			{Name: "Expiry", To: "Expired", On: "expire"},
+		}},
+		{Name: "Signed"},
+		{Name: "Expired"},
+	}}}
+	if edit != nil {
+		edit(c)
+	}
+	return c
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		edit       func(c *slc.Contract)
+		approve    []string
+		ids        []string
+		unapproved int
+		bump       string
+		suggested  string
+	}{
+		{name: "unchanged", bump: "none"},
+		{
+			name: "metadata",
+			edit: func(c *slc.Contract) { c.Name = "Renamed" },
+			ids:  []string{"metadata:name"}, bump: "patch", suggested: "v1.2.4",
+		},
+		{
+			name: "state added",
+			edit: func(c *slc.Contract) {
+				c.State.States = append(c.State.States, slc.State{Name: "Archived"})
+				c.State.States[1].Transitions = []slc.Transition{{Name: "Archive", To: "Archived", On: "archive"}}
+			},
+			ids: []string{"state-added:Archived", "transition-added:Signed/Archive"}, bump: "minor", suggested: "v1.3.0",
+		},
+		{
+			name: "looser conditions",
+			edit: func(c *slc.Contract) { c.State.States[0].Transitions[0].Conditions = nil },
+			ids:  []string{"transition-conditions:Draft/Signing"}, bump: "minor", suggested: "v1.3.0",
+		},
+		{
+			name: "stricter conditions",
+			edit: func(c *slc.Contract) {
+				c.State.States[0].Transitions[0].Conditions = append(c.State.States[0].Transitions[0].Conditions, slc.Condition{Name: "data.paid", Value: "true"})
+			},
+			ids: []string{"transition-conditions:Draft/Signing"}, unapproved: 1, bump: "major", suggested: "v2.0.0",
+		},
+		{
+			name: "transition removed",
+			edit: func(c *slc.Contract) { c.State.States[0].Transitions = c.State.States[0].Transitions[:1] },
+			// Expired can no longer be entered, but it is still defined.
+			ids:        []string{"transition-removed:Draft/Expiry", "event-removed:expire"},
+			unapproved: 2, bump: "major", suggested: "v2.0.0",
+		},
+		{
+			name: "transition added ahead of one it shadows",
+			edit: func(c *slc.Contract) {
+				fast := slc.Transition{Name: "Fast Track", To: "Expired", On: "sign"}
+				c.State.States[0].Transitions = append([]slc.Transition{fast}, c.State.States[0].Transitions...)
+			},
+			ids:        []string{"transition-added:Draft/Fast Track"},
+			unapproved: 1, bump: "major", suggested: "v2.0.0",
+		},
+		{
+			name: "transition added after one on the same event",
+			edit: func(c *slc.Contract) {
+				c.State.States[0].Transitions = append(c.State.States[0].Transitions, slc.Transition{Name: "Fallback", To: "Expired", On: "sign"})
+			},
+			ids:  []string{"transition-added:Draft/Fallback"},
+			bump: "minor", suggested: "v1.3.0",
+		},
+		{
+			name:    "approved breaking change",
+			edit:    func(c *slc.Contract) { c.State.States[0].Transitions[1].To = "Signed" },
+			approve: []string{"transition-target:Draft/Expiry"},
+			ids:     []string{"transition-target:Draft/Expiry"}, bump: "major", suggested: "v2.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := checkCompatibility(compatContract(nil), compatContract(tt.edit), tt.approve)
+			var ids []string
+			for _, f := range r.Findings {
+				ids = append(ids, f.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("checkCompatibility() findings = %q, want %q", ids, tt.ids)
+			}
+			if r.Unapproved != tt.unapproved {
+				t.Errorf("checkCompatibility() unapproved = %d, want %d", r.Unapproved, tt.unapproved)
+			}
+			if r.Bump != tt.bump || r.SuggestedVersion != tt.suggested {
+				t.Errorf("checkCompatibility() = %s bump to %q, want %s bump to %q", r.Bump, r.SuggestedVersion, tt.bump, tt.suggested)
+			}
+		})
+	}
+}
+
+func TestGuardContains(t *testing.T) {
+	tests := []struct {
+		outer, inner string
+		want         bool
+	}{
+		{outer: "", inner: "", want: true},
+		{outer: "[a = 1]", inner: "", want: true},
+		{outer: "", inner: "[a = 1]", want: false},
+		{outer: "[a = 1 and b = 2]", inner: "[b = 2]", want: true},
+		{outer: "[a = 1]", inner: "[a = 1 and b = 2]", want: false},
+		{outer: "[a = 1]", inner: "[a = 2]", want: false},
+	}
+	for _, tt := range tests {
+		if got := guardContains(tt.outer, tt.inner); got != tt.want {
+			t.Errorf("guardContains(%q, %q) = %v, want %v", tt.outer, tt.inner, got, tt.want)
+		}
+	}
+}
+
+func TestDeclaredBump(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          string
+	}{
+		{before: "v1.2.3", after: "v2.0.0", want: "major"},
+		{before: "v1.2.3", after: "1.3.0", want: "minor"},
+		{before: "v1.2.3", after: "v1.2.4-rc.1", want: "patch"},
+		{before: "v1.2.3", after: "v1.2.3", want: "none"},
+		{before: "v1.2.3", after: "v1.1.9", want: ""},
+		{before: "v1.2.3", after: "draft", want: ""},
+	}
+	for _, tt := range tests {
+		got := declaredBump(semverPattern.FindStringSubmatch(tt.before), semverPattern.FindStringSubmatch(tt.after))
+		if got != tt.want {
+			t.Errorf("declaredBump(%s, %s) = %q, want %q", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
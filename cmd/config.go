@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/spf13/viper"
 )
 
+var ErrNoConfigFile = errors.New("no config file found")
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 }
@@ -32,7 +35,7 @@ func configExecute(cmd *cobra.Command, args []string) {
 func Config() (ContractCLIConfig, error) {
 	cfg := viper.ConfigFileUsed()
 	if cfg == "" {
-		return ContractCLIConfig{}, fmt.Errorf("no config file found")
+		return ContractCLIConfig{}, ErrNoConfigFile
 	}
 	file, err := os.ReadFile(cfg)
 	if err != nil {
@@ -0,0 +1,505 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/decombine/slc"
+)
+
+// changeKind identifies a kind of semantic change between two versions of a contract.
+type changeKind string
+
+const (
+	changeMetadata          changeKind = "metadata"
+	changeInitial           changeKind = "initial-state"
+	changeStateAdded        changeKind = "state-added"
+	changeStateRemoved      changeKind = "state-removed"
+	changeStateRenamed      changeKind = "state-renamed"
+	changeTransitionAdded   changeKind = "transition-added"
+	changeTransitionRemoved changeKind = "transition-removed"
+	changeTransitionTarget  changeKind = "transition-target"
+	changeTransitionEvent   changeKind = "transition-event"
+	changeConditions        changeKind = "transition-conditions"
+	changeAction            changeKind = "action"
+)
+
+// contractChange is a single difference between two versions of a contract. State names the
+// state in the new version, or in the old version when it was removed.
+type contractChange struct {
+	Kind       changeKind `json:"kind"`
+	State      string     `json:"state,omitempty"`
+	Transition string     `json:"transition,omitempty"`
+	Field      string     `json:"field,omitempty"`
+	Old        string     `json:"old,omitempty"`
+	New        string     `json:"new,omitempty"`
+	Message    string     `json:"message"`
+	// shadowed names the existing transition an added transition takes events from, because
+	// it comes first with a subset of its conditions.
+	shadowed string
+}
+
+// sign returns + for additions, - for removals and ~ for modifications.
+func (c contractChange) sign() string {
+	switch {
+	case c.Kind == changeStateAdded || c.Kind == changeTransitionAdded || (c.Field != "" && c.Old == ""):
+		return "+"
+	case c.Kind == changeStateRemoved || c.Kind == changeTransitionRemoved || (c.Field != "" && c.New == ""):
+		return "-"
+	}
+	return "~"
+}
+
+// section groups a change for display.
+func (c contractChange) section() string {
+	switch c.Kind {
+	case changeMetadata, changeInitial:
+		return "Contract"
+	case changeStateAdded, changeStateRemoved, changeStateRenamed:
+		return "States"
+	case changeAction:
+		return "Actions"
+	}
+	return "Transitions"
+}
+
+var changeSections = []string{"Contract", "States", "Transitions", "Actions"}
+
+// diffContracts compares two versions of a contract at the level of its state machine.
+// States are matched by name, or as renamed when a removed and an added state have mostly
+// the same transitions and actions.
+func diffContracts(a, b *slc.Contract) []contractChange {
+	var changes []contractChange
+
+	// Metadata is everything but the state machine's states.
+	metaA, metaB := *a, *b
+	metaA.State.States, metaB.State.States = nil, nil
+	metaA.State.Initial, metaB.State.Initial = "", ""
+	for _, f := range documentChanges(jsonDocument(metaA), jsonDocument(metaB), "") {
+		changes = append(changes, contractChange{Kind: changeMetadata, Field: f.Path, Old: f.Old, New: f.New, Message: f.message()})
+	}
+
+	oldStates := make(map[string]slc.State, len(a.State.States))
+	for _, s := range a.State.States {
+		oldStates[s.Name] = s
+	}
+	newStates := make(map[string]slc.State, len(b.State.States))
+	for _, s := range b.State.States {
+		newStates[s.Name] = s
+	}
+	var removed, added []string
+	for _, s := range a.State.States {
+		if _, ok := newStates[s.Name]; !ok {
+			removed = append(removed, s.Name)
+		}
+	}
+	for _, s := range b.State.States {
+		if _, ok := oldStates[s.Name]; !ok {
+			added = append(added, s.Name)
+		}
+	}
+
+	// rename maps the old name of every matched state to its new name.
+	rename := make(map[string]string)
+	for _, s := range a.State.States {
+		if _, ok := newStates[s.Name]; ok {
+			rename[s.Name] = s.Name
+		}
+	}
+	for from, to := range renamedStates(a, b, removed, added) {
+		rename[from] = to
+	}
+	mapped := func(name string) string {
+		if to, ok := rename[name]; ok {
+			return to
+		}
+		return name
+	}
+
+	if mapped(a.State.Initial) != b.State.Initial {
+		changes = append(changes, contractChange{
+			Kind: changeInitial, Old: a.State.Initial, New: b.State.Initial,
+			Message: fmt.Sprintf("new instances start in %q instead of %q", b.State.Initial, a.State.Initial),
+		})
+	}
+
+	renamedTo := make(map[string]bool)
+	for _, s := range a.State.States {
+		to, ok := rename[s.Name]
+		switch {
+		case !ok:
+			changes = append(changes, contractChange{Kind: changeStateRemoved, State: s.Name, Message: fmt.Sprintf("state %q removed", s.Name)})
+		case to != s.Name:
+			renamedTo[to] = true
+			changes = append(changes, contractChange{
+				Kind: changeStateRenamed, State: to, Old: s.Name, New: to,
+				Message: fmt.Sprintf("state %q renamed to %q", s.Name, to),
+			})
+		}
+	}
+	for _, name := range added {
+		if !renamedTo[name] {
+			changes = append(changes, contractChange{Kind: changeStateAdded, State: name, Message: fmt.Sprintf("state %q added", name)})
+		}
+	}
+
+	for _, ns := range b.State.States {
+		// Added states are compared with an empty state.
+		var prev slc.State
+		for _, s := range a.State.States {
+			if rename[s.Name] == ns.Name {
+				prev = s
+			}
+		}
+		changes = append(changes, diffTransitions(ns.Name, prev.Transitions, ns.Transitions, mapped)...)
+		changes = append(changes, diffAction(ns.Name, "entry", prev.Entry, ns.Entry)...)
+		changes = append(changes, diffAction(ns.Name, "exit", prev.Exit, ns.Exit)...)
+	}
+	return changes
+}
+
+// renamedStates pairs removed and added states whose transitions and actions are mostly the
+// same, best matches first.
+func renamedStates(a, b *slc.Contract, removed, added []string) map[string]string {
+	type candidate struct {
+		from, to string
+		score    float64
+	}
+	var candidates []candidate
+	for _, from := range removed {
+		fa := stateFeatures(a, from)
+		for _, to := range added {
+			if score := jaccard(fa, stateFeatures(b, to)); score >= 0.5 {
+				candidates = append(candidates, candidate{from, to, score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	renames := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, c := range candidates {
+		if _, ok := renames[c.from]; ok || taken[c.to] {
+			continue
+		}
+		renames[c.from] = c.to
+		taken[c.to] = true
+	}
+	return renames
+}
+
+// stateFeatures describes a state by its actions, outgoing transitions and incoming
+// transitions without using its own name.
+func stateFeatures(c *slc.Contract, name string) map[string]bool {
+	features := make(map[string]bool)
+	for _, s := range c.State.States {
+		for _, t := range s.Transitions {
+			if s.Name == name {
+				to := t.To
+				if to == name {
+					to = "self"
+				}
+				features["out:"+t.On+"->"+to+conditionGuard(t)] = true
+			}
+			if t.To == name && s.Name != name {
+				features["in:"+s.Name+":"+t.On] = true
+			}
+		}
+		if s.Name != name {
+			continue
+		}
+		if len(describeAction(s.Entry)) > 0 {
+			features["entry:"+jsonString(s.Entry)] = true
+		}
+		if len(describeAction(s.Exit)) > 0 {
+			features["exit:"+jsonString(s.Exit)] = true
+		}
+	}
+	return features
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// diffTransitions compares the transitions of a state. Transitions are matched by name, then
+// by event and target, then by event and finally by target. mapped gives the new name of an
+// old state.
+func diffTransitions(state string, before, after []slc.Transition, mapped func(string) string) []contractChange {
+	matched := make(map[int]int)
+	used := make(map[int]bool)
+	passes := []func(o, n slc.Transition) bool{
+		func(o, n slc.Transition) bool { return o.Name != "" && o.Name == n.Name },
+		func(o, n slc.Transition) bool { return o.On == n.On && mapped(o.To) == n.To },
+		func(o, n slc.Transition) bool { return o.On == n.On },
+		func(o, n slc.Transition) bool { return mapped(o.To) == n.To },
+	}
+	for _, same := range passes {
+		for i, o := range before {
+			if _, ok := matched[i]; ok {
+				continue
+			}
+			for j, n := range after {
+				if !used[j] && same(o, n) {
+					matched[i] = j
+					used[j] = true
+					break
+				}
+			}
+		}
+	}
+
+	var changes []contractChange
+	for i, o := range before {
+		j, ok := matched[i]
+		if !ok {
+			changes = append(changes, contractChange{
+				Kind: changeTransitionRemoved, State: state, Transition: transitionName(o), Old: o.On,
+				Message: fmt.Sprintf("%q can no longer move to %q on %s", state, mapped(o.To), describeTrigger(o)),
+			})
+			continue
+		}
+		n := after[j]
+		if o.On != n.On {
+			changes = append(changes, contractChange{
+				Kind: changeTransitionEvent, State: state, Transition: transitionName(n), Old: o.On, New: n.On,
+				Message: fmt.Sprintf("%q now moves to %q on %s instead of %s", state, n.To, n.On, o.On),
+			})
+		}
+		if mapped(o.To) != n.To {
+			changes = append(changes, contractChange{
+				Kind: changeTransitionTarget, State: state, Transition: transitionName(n), Old: mapped(o.To), New: n.To,
+				Message: fmt.Sprintf("%q now moves to %q instead of %q on %s", state, n.To, mapped(o.To), n.On),
+			})
+		}
+		if og, ng := sortedGuard(o), sortedGuard(n); og != ng {
+			changes = append(changes, contractChange{
+				Kind: changeConditions, State: state, Transition: transitionName(n), Old: og, New: ng,
+				Message: fmt.Sprintf("%q moves to %q on %s %s instead of %s", state, n.To, n.On, guardText(ng), guardText(og)),
+			})
+		}
+	}
+	for j, n := range after {
+		if used[j] {
+			continue
+		}
+		c := contractChange{
+			Kind: changeTransitionAdded, State: state, Transition: transitionName(n), New: n.On,
+			Message: fmt.Sprintf("%q can now move to %q on %s", state, n.To, describeTrigger(n)),
+		}
+		for k := j + 1; k < len(after); k++ {
+			if used[k] && shadowingTransition(after, k) == j {
+				c.shadowed = transitionName(after[k])
+				break
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// describeTrigger returns a transition's event and its conditions.
+func describeTrigger(t slc.Transition) string {
+	if guard := sortedGuard(t); guard != "" {
+		return t.On + " " + guardText(guard)
+	}
+	return t.On
+}
+
+// sortedGuard returns a transition's conditions independent of their order.
+func sortedGuard(t slc.Transition) string {
+	conditions := append([]slc.Condition(nil), t.Conditions...)
+	sort.Slice(conditions, func(i, j int) bool {
+		if conditions[i].Name != conditions[j].Name {
+			return conditions[i].Name < conditions[j].Name
+		}
+		return conditions[i].Value < conditions[j].Value
+	})
+	return conditionGuard(slc.Transition{Conditions: conditions})
+}
+
+func guardText(guard string) string {
+	if guard == "" {
+		return "without conditions"
+	}
+	return "when " + strings.Trim(guard, "[]")
+}
+
+// diffAction compares an Entry or Exit action. Kubernetes actions are compared by position,
+// field by field.
+func diffAction(state, which string, before, after slc.Action) []contractChange {
+	var changes []contractChange
+	add := func(f fieldChange, message string) {
+		changes = append(changes, contractChange{
+			Kind: changeAction, State: state, Field: which + "." + f.Path, Old: f.Old, New: f.New,
+			Message: fmt.Sprintf("%s action of %q %s", which, state, message),
+		})
+	}
+	if before.ActionType != after.ActionType {
+		f := fieldChange{Path: "actionType", Old: before.ActionType, New: after.ActionType}
+		add(f, "has "+f.message())
+	}
+	for i := 0; i < max(len(before.KubernetesActions), len(after.KubernetesActions)); i++ {
+		path := fmt.Sprintf("kubernetesActions[%d]", i)
+		switch {
+		case i >= len(before.KubernetesActions):
+			desc := describeKubernetesAction(after.KubernetesActions[i])
+			add(fieldChange{Path: path, New: desc}, "now does: "+desc)
+		case i >= len(after.KubernetesActions):
+			desc := describeKubernetesAction(before.KubernetesActions[i])
+			add(fieldChange{Path: path, Old: desc}, "no longer does: "+desc)
+		default:
+			for _, f := range documentChanges(jsonDocument(before.KubernetesActions[i]), jsonDocument(after.KubernetesActions[i]), path) {
+				add(f, "has "+f.message())
+			}
+		}
+	}
+	return changes
+}
+
+func describeKubernetesAction(ka slc.KubernetesAction) string {
+	return describeAction(slc.Action{KubernetesActions: []slc.KubernetesAction{ka}})[0]
+}
+
+// fieldChange is a difference between two documents at a field path.
+type fieldChange struct {
+	Path, Old, New string
+}
+
+func (f fieldChange) message() string {
+	switch {
+	case f.Old == "":
+		return fmt.Sprintf("%s set to %s", f.Path, f.New)
+	case f.New == "":
+		return fmt.Sprintf("%s %s removed", f.Path, f.Old)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", f.Path, f.Old, f.New)
+}
+
+// jsonDocument converts a value to the generic tree encoding/json decodes into.
+func jsonDocument(v interface{}) interface{} {
+	var doc interface{}
+	data, _ := json.Marshal(v)
+	_ = json.Unmarshal(data, &doc)
+	return doc
+}
+
+func jsonString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// documentChanges lists the fields that differ between two generic documents. Zero values
+// and absent fields are the same.
+func documentChanges(a, b interface{}, path string) []fieldChange {
+	if isZeroDocument(a) && isZeroDocument(b) {
+		return nil
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok && !isZeroDocument(b) {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var out []fieldChange
+		for _, k := range keys {
+			out = append(out, documentChanges(av[k], bv[k], join(k))...)
+		}
+		return out
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok && !isZeroDocument(b) {
+			break
+		}
+		var out []fieldChange
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			var ae, be interface{}
+			if i < len(av) {
+				ae = av[i]
+			}
+			if i < len(bv) {
+				be = bv[i]
+			}
+			out = append(out, documentChanges(ae, be, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return out
+	case nil:
+		if _, ok := b.(map[string]interface{}); ok {
+			return documentChanges(map[string]interface{}{}, b, path)
+		}
+		if _, ok := b.([]interface{}); ok {
+			return documentChanges([]interface{}{}, b, path)
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	return []fieldChange{{Path: path, Old: documentText(a), New: documentText(b)}}
+}
+
+// isZeroDocument reports whether a document holds only zero values.
+func isZeroDocument(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		for _, e := range v {
+			if !isZeroDocument(e) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// documentText renders a document value for a change message. Absent values and empty
+// strings and collections render as "".
+func documentText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		if isZeroDocument(v) {
+			return ""
+		}
+	}
+	return jsonString(v)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func TestJaccard(t *testing.T) {
+	set := func(keys ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, k := range keys {
+			m[k] = true
+		}
+		return m
+	}
+	tests := []struct {
+		a, b map[string]bool
+		want float64
+	}{
+		{a: set(), b: set(), want: 0},
+		{a: set("x"), b: set(), want: 0},
+		{a: set("x", "y"), b: set("x", "y"), want: 1},
+		{a: set("x", "y"), b: set("y", "z"), want: 1.0 / 3},
+		{a: set("x", "y", "z"), b: set("x", "y"), want: 2.0 / 3},
+	}
+	for _, tt := range tests {
+		if got := jaccard(tt.a, tt.b); got != tt.want {
+			t.Errorf("jaccard(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffContractsRenames(t *testing.T) {
+	chain := func(edits ...func(c *slc.Contract)) func(c *slc.Contract) {
+		return func(c *slc.Contract) {
+			for _, edit := range edits {
+				if edit != nil {
+					edit(c)
+				}
+			}
+		}
+	}
+	tests := []struct {
+		name string
+		base func(c *slc.Contract)
+		edit func(c *slc.Contract)
+		want []string
+	}{
+		{
+			name: "renamed state",
+			edit: func(c *slc.Contract) {
+				c.State.States[1].Name = "Completed"
+				c.State.States[0].Transitions[0].To = "Completed"
+			},
+			want: []string{"state-renamed:Completed"},
+		},
+		{
+			name: "renamed initial state",
+			edit: func(c *slc.Contract) {
+				c.State.Initial = "Drafting"
+				c.State.States[0].Name = "Drafting"
+			},
+			want: []string{"state-renamed:Drafting"},
+		},
+		{
+			name: "unrelated state",
+			edit: func(c *slc.Contract) {
+				c.State.States[1] = slc.State{Name: "Archived", Transitions: []slc.Transition{{Name: "Restore", To: "Draft", On: "restore"}}}
+			},
+			want: []string{"state-removed:Signed", "state-added:Archived", "transition-added:Archived/Restore"},
+		},
+		{
+			name: "best match first",
+			base: func(c *slc.Contract) {
+				c.State.States[1].Transitions = []slc.Transition{{Name: "Archive", To: "Expired", On: "archive"}}
+			},
+			edit: func(c *slc.Contract) {
+				// Closed shares every feature of Signed, Completed only its transition.
+				archive := []slc.Transition{{Name: "Archive", To: "Expired", On: "archive"}}
+				c.State.States[0].Transitions[0].To = "Closed"
+				c.State.States = []slc.State{c.State.States[0], {Name: "Completed", Transitions: archive}, {Name: "Closed", Transitions: archive}, c.State.States[2]}
+			},
+			want: []string{"state-renamed:Closed", "state-added:Completed", "transition-added:Completed/Archive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range diffContracts(compatContract(tt.base), compatContract(chain(tt.base, tt.edit))) {
+				got = append(got, classifyChange(c).ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffContracts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []slc.Transition
+		after  []slc.Transition
+		want   []changeKind
+	}{
+		{
+			name:   "matched by name",
+			before: []slc.Transition{{Name: "Signing", To: "A", On: "sign"}},
+			after:  []slc.Transition{{Name: "Signing", To: "B", On: "approve"}},
+			want:   []changeKind{changeTransitionEvent, changeTransitionTarget},
+		},
+		{
+			name:   "matched by event and target before event",
+			before: []slc.Transition{{To: "A", On: "sign"}},
+			after:  []slc.Transition{{To: "B", On: "sign"}, {Name: "Renamed", To: "A", On: "sign"}},
+			want:   []changeKind{changeTransitionAdded},
+		},
+		{
+			name:   "matched by event before target",
+			before: []slc.Transition{{To: "A", On: "sign"}},
+			after:  []slc.Transition{{To: "A", On: "approve"}, {To: "B", On: "sign"}},
+			want:   []changeKind{changeTransitionTarget, changeTransitionAdded},
+		},
+		{
+			name:   "matched by target",
+			before: []slc.Transition{{To: "A", On: "sign"}},
+			after:  []slc.Transition{{To: "A", On: "approve"}},
+			want:   []changeKind{changeTransitionEvent},
+		},
+		{
+			name:   "target follows a renamed state",
+			before: []slc.Transition{{To: "Old", On: "sign"}},
+			after:  []slc.Transition{{To: "New", On: "approve"}},
+			want:   []changeKind{changeTransitionEvent},
+		},
+		{
+			name:   "conditions in another order",
+			before: []slc.Transition{{To: "A", On: "sign", Conditions: []slc.Condition{{Name: "x", Value: "1"}, {Name: "y", Value: "2"}}}},
+			after:  []slc.Transition{{To: "A", On: "sign", Conditions: []slc.Condition{{Name: "y", Value: "2"}, {Name: "x", Value: "1"}}}},
+		},
+		{
+			name:   "unmatched",
+			before: []slc.Transition{{To: "A", On: "sign"}},
+			after:  []slc.Transition{{To: "B", On: "approve"}},
+			want:   []changeKind{changeTransitionRemoved, changeTransitionAdded},
+		},
+	}
+	mapped := func(name string) string {
+		if name == "Old" {
+			return "New"
+		}
+		return name
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []changeKind
+			for _, c := range diffTransitions("S", tt.before, tt.after, mapped) {
+				got = append(got, c.Kind)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffTransitions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
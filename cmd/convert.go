@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+var ErrLossyConversion = errors.New("conversion would lose data")
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+	convertCmd.Flags().StringP("to", "t", "", "The output format. Options: json, yaml, toml (default inferred from --file)")
+	convertCmd.Flags().String("from", "", "The input format. Options: json, yaml, toml (default inferred from the extension or content)")
+	convertCmd.Flags().String("file", "", "Write the converted contract to this file instead of stdout")
+}
+
+var convertCmd = &cobra.Command{
+	Use:   "convert CONTRACT",
+	Short: "Convert a Smart Legal Contract between JSON, YAML and TOML",
+	Long: `Convert a Smart Legal Contract between JSON, YAML and TOML. Use - to read the contract from stdin.
+
+The document is converted as written: fields are kept in their order where the output format allows it, values
+such as Kustomization intervals keep their spelling, and fields unknown to this version of contract are carried
+over. The result is read back and compared with the input, and the conversion fails rather than drop anything,
+e.g. a null that TOML cannot represent inside an array.`,
+	Example: `  contract convert contract.yaml --to toml
+  contract convert contract.json --file contract.yaml`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		from, _ := cmd.Flags().GetString("from")
+		file, _ := cmd.Flags().GetString("file")
+		if to == "" {
+			to = contractFileFormat(file)
+		}
+		if to == "" {
+			return errors.New("no output format: use --to or a --file with a .json, .yaml or .toml extension")
+		}
+		if !isContractFormat(to) {
+			return fmt.Errorf("%w: %s", ErrFormatNotSupported, to)
+		}
+		if from != "" && !isContractFormat(from) {
+			return fmt.Errorf("%w: %s", ErrFormatNotSupported, from)
+		}
+
+		var data []byte
+		var err error
+		format := from
+		if args[0] == "-" {
+			if data, err = readStdin(cmd.InOrStdin()); err != nil {
+				return err
+			}
+			if format == "" {
+				format = contractFormat("", data)
+			}
+		} else {
+			var detected string
+			if data, detected, err = readContract(args[0]); err != nil {
+				return err
+			}
+			if format == "" {
+				format = detected
+			}
+		}
+
+		out, err := convertContract(data, format, to)
+		if err != nil {
+			return err
+		}
+		if file == "" || file == "-" {
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		}
+		return os.WriteFile(file, out, 0644)
+	},
+}
+
+func isContractFormat(format string) bool {
+	return format == "json" || format == "yaml" || format == "toml"
+}
+
+// convertContract converts a contract document from one format to another and checks that
+// the result reads back as the same document.
+func convertContract(data []byte, from, to string) ([]byte, error) {
+	doc, err := decodeDocument(data, from)
+	if err != nil {
+		return nil, err
+	}
+	// Decode the document as JSON, whose tags every part of the contract has, to check that it
+	// is a contract.
+	var canonical bytes.Buffer
+	if err := encodeDocument(&canonical, doc, "json"); err != nil {
+		return nil, err
+	}
+	if _, err := decodeContract(canonical.Bytes(), "json"); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := encodeDocument(&out, doc, to); err != nil {
+		return nil, fmt.Errorf("error encoding %s: %w", strings.ToUpper(to), err)
+	}
+	back, err := decodeDocument(out.Bytes(), to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the %s output cannot be read back: %v", ErrLossyConversion, strings.ToUpper(to), err)
+	}
+	if path, ok := sameDocument(normalizeDocument(doc), normalizeDocument(back), ""); !ok {
+		return nil, fmt.Errorf("%w: %s differs after converting to %s", ErrLossyConversion, path, strings.ToUpper(to))
+	}
+	return out.Bytes(), nil
+}
+
+// decodeDocument decodes a contract into a tree of yaml.MapSlice, []interface{} and scalars
+// that keeps the order of keys.
+func decodeDocument(data []byte, format string) (interface{}, error) {
+	switch format {
+	case "json", "yaml":
+		// JSON is read as YAML so the order of keys is kept.
+		var doc interface{}
+		if err := yaml.UnmarshalWithOptions(data, &doc, yaml.UseOrderedMap()); err != nil {
+			return nil, fmt.Errorf("error decoding %s contract: %w", strings.ToUpper(format), err)
+		}
+		return doc, nil
+	case "toml":
+		var m map[string]interface{}
+		md, err := toml.Decode(string(data), &m)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding TOML contract: %w", err)
+		}
+		order := make(map[string]int)
+		for i, k := range md.Keys() {
+			if _, ok := order[k.String()]; !ok {
+				order[k.String()] = i
+			}
+		}
+		return orderTOML(m, order, nil), nil
+	}
+	return nil, ErrFormatNotSupported
+}
+
+// orderTOML orders the keys of a decoded TOML table as they appear in the document. Elements
+// of arrays of tables share the key paths of their array.
+func orderTOML(v interface{}, order map[string]int, prefix toml.Key) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		rank := func(k string) int {
+			if i, ok := order[append(prefix[:len(prefix):len(prefix)], k).String()]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			ri, rj := rank(keys[i]), rank(keys[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return keys[i] < keys[j]
+		})
+		ms := make(yaml.MapSlice, 0, len(keys))
+		for _, k := range keys {
+			ms = append(ms, yaml.MapItem{Key: k, Value: orderTOML(v[k], order, append(prefix[:len(prefix):len(prefix)], k))})
+		}
+		return ms
+	case []map[string]interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = orderTOML(e, order, prefix)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = orderTOML(e, order, prefix)
+		}
+		return out
+	}
+	return v
+}
+
+// encodeDocument writes a document tree in the given format.
+func encodeDocument(w *bytes.Buffer, doc interface{}, format string) error {
+	switch format {
+	case "json":
+		if err := encodeJSONDocument(w, doc, ""); err != nil {
+			return err
+		}
+		w.WriteString("\n")
+		return nil
+	case "yaml":
+		data, err := yaml.MarshalWithOptions(doc, yaml.IndentSequence(true), yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+		return nil
+	case "toml":
+		table, ok := tomlValue(doc).(map[string]interface{})
+		if !ok {
+			return errors.New("the top level of a TOML document must be a table")
+		}
+		return toml.NewEncoder(w).Encode(table)
+	}
+	return ErrFormatNotSupported
+}
+
+// encodeJSONDocument writes a document tree as indented JSON, keeping the order of keys.
+func encodeJSONDocument(w *bytes.Buffer, v interface{}, indent string) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		if len(v) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+		w.WriteString("{\n")
+		for i, item := range v {
+			key, _ := json.Marshal(fmt.Sprint(item.Key))
+			fmt.Fprintf(w, "%s  %s: ", indent, key)
+			if err := encodeJSONDocument(w, item.Value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString(indent + "}")
+		return nil
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteString("[\n")
+		for i, e := range v {
+			w.WriteString(indent + "  ")
+			if err := encodeJSONDocument(w, e, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString(indent + "]")
+		return nil
+	case time.Time:
+		fmt.Fprintf(w, "%q", v.Format(time.RFC3339Nano))
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	return nil
+}
+
+// tomlValue converts a document tree for the TOML encoder, which has no null. Null fields are
+// left out; a null inside an array is kept so the conversion fails.
+func tomlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			if item.Value != nil {
+				m[fmt.Sprint(item.Key)] = tomlValue(item.Value)
+			}
+		}
+		return m
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = tomlValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// normalizeDocument makes trees decoded from different formats comparable: maps become
+// map[string]interface{} without null fields, numbers become exact rationals and times
+// become RFC 3339 strings.
+func normalizeDocument(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			if item.Value != nil {
+				m[fmt.Sprint(item.Key)] = normalizeDocument(item.Value)
+			}
+		}
+		return m
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = normalizeDocument(e)
+		}
+		return out
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(v.String()); ok {
+			return r.RatString()
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if r, ok := new(big.Rat).SetString(fmt.Sprint(v)); ok {
+			return r.RatString()
+		}
+	}
+	return v
+}
+
+// sameDocument compares two normalized trees and returns the path of the first difference.
+func sameDocument(a, b interface{}, path string) (string, bool) {
+	where := path
+	if where == "" {
+		where = "the document"
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		bm, ok := b.(map[string]interface{})
+		if !ok {
+			return where, false
+		}
+		keys := make([]string, 0, len(a)+len(bm))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			next := k
+			if path != "" {
+				next = path + "." + k
+			}
+			if p, ok := sameDocument(a[k], bm[k], next); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case []interface{}:
+		bs, ok := b.([]interface{})
+		if !ok || len(a) != len(bs) {
+			return where, false
+		}
+		for i := range a {
+			if p, ok := sameDocument(a[i], bs[i], fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	}
+	if !reflect.DeepEqual(a, b) {
+		return where, false
+	}
+	return "", true
+}
+
+// contractFileFormat returns the format implied by a file name, or "" when it has none.
+func contractFileFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml", ".toml":
+		return contractFormat(name, nil)
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import "testing"
+
+// convertFixture is a contract with a complete Kustomization spec and a key that is not in
+// the schema.
+const convertFixture = `{
+  "version": "v1.0.0",
+  "name": "Conversion",
+  "x-reviewed": {"by": "legal", "on": "2025-01-01", "rounds": [1, 2.5]},
+  "state": {
+    "initial": "Draft",
+    "states": [
+      {
+        "name": "Draft",
+        "entry": {
+          "actionType": "KubernetesAction",
+          "kubernetesActions": [
+            {
+              "namespace": "default",
+              "kustomizationSpec": {
+                "commonMetadata": {"labels": {"app": "contract"}, "annotations": {"note": "multi\nline"}},
+                "dependsOn": [{"name": "base", "namespace": "flux-system"}],
+                "decryption": {"provider": "sops", "secretRef": {"name": "sops-age"}},
+                "interval": "10m",
+                "retryInterval": "1m30s",
+                "kubeConfig": {"secretRef": {"name": "kubeconfig", "key": "value"}},
+                "path": "contracts/workloads/draft",
+                "postBuild": {
+                  "substitute": {"region": "eu-west-1"},
+                  "substituteFrom": [{"kind": "ConfigMap", "name": "vars", "optional": true}]
+                },
+                "prune": true,
+                "deletionPolicy": "Delete",
+                "healthChecks": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "app", "namespace": "default"}],
+                "namePrefix": "draft-",
+                "nameSuffix": "-v1",
+                "patches": [{"patch": "- op: add\n  path: /metadata/labels/x\n  value: y\n", "target": {"kind": "Deployment", "name": "app"}}],
+                "images": [{"name": "app", "newName": "registry.example.com/app", "newTag": "1.2.3"}],
+                "serviceAccountName": "contract",
+                "sourceRef": {"kind": "GitRepository", "name": "contract", "namespace": "flux-system"},
+                "suspend": false,
+                "targetNamespace": "contracts",
+                "timeout": "5m",
+                "force": false,
+                "wait": true,
+                "components": ["../components/audit"]
+              }
+            }
+          ]
+        },
+        "transitions": [
+          {
+            "name": "Signing",
+            "to": "Signed",
+            "on": "com.decombine.signature.sign",
+            "conditions": [{"name": "data.signature.validated", "value": "true"}]
+          }
+        ]
+      },
+      {"name": "Signed"}
+    ]
+  }
+}
+`
+
+func TestConvertContractRoundTrip(t *testing.T) {
+	sources := map[string][]byte{"json": []byte(convertFixture)}
+	for _, format := range []string{"yaml", "toml"} {
+		out, err := convertContract([]byte(convertFixture), "json", format)
+		if err != nil {
+			t.Fatalf("convertContract(json, %s) error = %v", format, err)
+		}
+		sources[format] = out
+	}
+
+	for _, from := range []string{"json", "yaml", "toml"} {
+		want, err := decodeDocument(sources[from], from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, to := range []string{"json", "yaml", "toml"} {
+			if to == from {
+				continue
+			}
+			t.Run(from+" to "+to, func(t *testing.T) {
+				out, err := convertContract(sources[from], from, to)
+				if err != nil {
+					t.Fatalf("convertContract() error = %v", err)
+				}
+				back, err := convertContract(out, to, from)
+				if err != nil {
+					t.Fatalf("convertContract() back error = %v", err)
+				}
+				got, err := decodeDocument(back, from)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path, ok := sameDocument(normalizeDocument(want), normalizeDocument(got), ""); !ok {
+					t.Errorf("%s differs after converting to %s and back:\n%s", path, to, back)
+				}
+			})
+		}
+	}
+}
+
+func TestConvertContractErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		to   string
+	}{
+		{name: "null in an array", in: `{"name": "c", "x-list": [1, null]}`, to: "toml"},
+		{name: "not a contract", in: `{"name": 1}`, to: "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := convertContract([]byte(tt.in), "json", tt.to); err == nil {
+				t.Error("convertContract() succeeded")
+			}
+		})
+	}
+}
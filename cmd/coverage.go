@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/decombine/contract/pkg/condition"
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+var ErrCoverageTooLow = errors.New("coverage is below the minimum")
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+	coverageCmd.Flags().StringArrayP("events", "e", []string{}, "CloudEvent fixture files or directories of them. Each file is replayed from the initial state.")
+	coverageCmd.Flags().StringArray("tests", []string{}, "Contract test files or directories whose cases for this contract are included")
+	coverageCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json, html")
+	coverageCmd.Flags().Float64("min-coverage", 0, "Fail when the total coverage percentage is below this value")
+}
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage CONTRACT --events DIR",
+	Short: "Report which states, transitions and conditions are exercised",
+	Long: `Replay CloudEvent fixtures and contract tests against a Smart Legal Contract and report coverage.
+
+Each fixture file (.json, .ndjson or .jsonl) is replayed from the initial state. Coverage is measured for:
+
+  states       states that were entered
+  transitions  transitions that fired
+  conditions   conditions that were evaluated both true and false
+
+The total is the share of all of these that were covered. Use --min-coverage to fail below a percentage. The html
+output includes the state graph with covered states and transitions highlighted.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eventPaths, _ := cmd.Flags().GetStringArray("events")
+		testPaths, _ := cmd.Flags().GetStringArray("tests")
+		output, _ := cmd.Flags().GetString("output")
+		minCoverage, _ := cmd.Flags().GetFloat64("min-coverage")
+		if output != "text" && output != "json" && output != "html" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+		if len(eventPaths) == 0 && len(testPaths) == 0 {
+			return errors.New("no fixtures: pass --events or --tests")
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		cov := newCoverage(c)
+
+		fixtures, err := findEventFixtures(eventPaths)
+		if err != nil {
+			return err
+		}
+		for _, f := range fixtures {
+			events, err := readEventsFile(f, cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("%s: %w", f, err)
+			}
+			m, err := newMachine(c)
+			if err != nil {
+				return err
+			}
+			m.observer = cov
+			m.start()
+			for _, e := range events {
+				if _, err := m.fire(e); err != nil {
+					cov.Errors = append(cov.Errors, fmt.Sprintf("%s: %v", f, err))
+					break
+				}
+			}
+			cov.Fixtures++
+		}
+
+		if len(testPaths) > 0 {
+			if err := cov.runTests(args[0], testPaths); err != nil {
+				return err
+			}
+		}
+
+		report := cov.report()
+		out := cmd.OutOrStdout()
+		switch output {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		case "html":
+			err = writeCoverageHTML(out, cov, report)
+		default:
+			printCoverage(out, report)
+		}
+		if err != nil {
+			return err
+		}
+		if report.Total.Percent < minCoverage {
+			return fmt.Errorf("%w: %.1f%% < %.1f%%", ErrCoverageTooLow, report.Total.Percent, minCoverage)
+		}
+		return nil
+	},
+}
+
+// coverage records what a set of runs exercised. It is attached to machines as their observer.
+type coverage struct {
+	contract    *slc.Contract
+	states      map[int]bool
+	transitions map[[2]int]bool
+	// outcomes records, per condition, whether it was seen true and false.
+	outcomes map[[3]int]*[2]bool
+	Fixtures int
+	Tests    int
+	Errors   []string
+}
+
+func newCoverage(c *slc.Contract) *coverage {
+	return &coverage{
+		contract:    c,
+		states:      make(map[int]bool),
+		transitions: make(map[[2]int]bool),
+		outcomes:    make(map[[3]int]*[2]bool),
+	}
+}
+
+func (cv *coverage) entered(state int) {
+	cv.states[state] = true
+}
+
+func (cv *coverage) evaluated(state, transition int, results []condition.Result) {
+	for k, r := range results {
+		key := [3]int{state, transition, k}
+		if cv.outcomes[key] == nil {
+			cv.outcomes[key] = &[2]bool{}
+		}
+		if r.Holds {
+			cv.outcomes[key][1] = true
+		} else {
+			cv.outcomes[key][0] = true
+		}
+	}
+}
+
+func (cv *coverage) fired(state, transition int) {
+	cv.transitions[[2]int{state, transition}] = true
+}
+
+// runTests runs the cases of every test file that targets the contract at contractPath.
+func (cv *coverage) runTests(contractPath string, paths []string) error {
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return err
+	}
+	want, _ := filepath.Abs(contractPath)
+	for _, f := range files {
+		tf, target, err := readTestFile(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		if abs, _ := filepath.Abs(target); abs != want {
+			continue
+		}
+		for _, tc := range tf.Tests {
+			r := runTestCase(cv.contract, tf.Deadlines, tc, cv)
+			if r.Err != nil {
+				cv.Errors = append(cv.Errors, fmt.Sprintf("%s: %s: %v", f, tc.Name, r.Err))
+			}
+			cv.Tests++
+		}
+	}
+	return nil
+}
+
+// coverageMetric is the covered share of one kind of item.
+type coverageMetric struct {
+	Covered int     `json:"covered"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+func newMetric(covered, total int) coverageMetric {
+	m := coverageMetric{Covered: covered, Total: total, Percent: 100}
+	if total > 0 {
+		m.Percent = float64(covered) * 100 / float64(total)
+	}
+	return m
+}
+
+type coverageReport struct {
+	Contract    string         `json:"contract"`
+	Fixtures    int            `json:"fixtures"`
+	Tests       int            `json:"tests"`
+	States      coverageMetric `json:"states"`
+	Transitions coverageMetric `json:"transitions"`
+	Conditions  coverageMetric `json:"conditions"`
+	Total       coverageMetric `json:"total"`
+	Uncovered   []string       `json:"uncovered,omitempty"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+func (cv *coverage) report() coverageReport {
+	r := coverageReport{Contract: cv.contract.Name, Fixtures: cv.Fixtures, Tests: cv.Tests, Errors: cv.Errors}
+	var states, transitions, conditions, coveredConditions int
+	seen := make(map[string]bool)
+	for i, s := range cv.contract.State.States {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		states++
+		if !cv.states[i] {
+			r.Uncovered = append(r.Uncovered, fmt.Sprintf("state %s was never entered", s.Name))
+		}
+		for j, t := range s.Transitions {
+			transitions++
+			if !cv.transitions[[2]int{i, j}] {
+				r.Uncovered = append(r.Uncovered, fmt.Sprintf("transition %s ── %s ─▶ %s never fired", s.Name, transitionName(t), t.To))
+			}
+			for k, cond := range t.Conditions {
+				conditions += 2
+				o := cv.outcomes[[3]int{i, j, k}]
+				if o == nil {
+					o = &[2]bool{}
+				}
+				for outcome, covered := range o {
+					if covered {
+						coveredConditions++
+						continue
+					}
+					r.Uncovered = append(r.Uncovered, fmt.Sprintf("condition %s = %q of %s/%s was never %t", cond.Name, cond.Value, s.Name, transitionName(t), outcome == 1))
+				}
+			}
+		}
+	}
+	r.States = newMetric(len(cv.states), states)
+	r.Transitions = newMetric(len(cv.transitions), transitions)
+	r.Conditions = newMetric(coveredConditions, conditions)
+	r.Total = newMetric(r.States.Covered+r.Transitions.Covered+r.Conditions.Covered, states+transitions+conditions)
+	return r
+}
+
+// findEventFixtures resolves files and directories into CloudEvent fixture files.
+func findEventFixtures(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".json", ".ndjson", ".jsonl":
+				if !d.IsDir() {
+					files = append(files, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func printCoverage(w io.Writer, r coverageReport) {
+	fmt.Fprintf(w, "%s %s from %d fixture(s) and %d test(s)\n\n", style.Render("Coverage of"), r.Contract, r.Fixtures, r.Tests)
+	for _, row := range []struct {
+		name string
+		m    coverageMetric
+	}{{"States", r.States}, {"Transitions", r.Transitions}, {"Conditions", r.Conditions}, {"Total", r.Total}} {
+		pct := fmt.Sprintf("%5.1f%%", row.m.Percent)
+		switch {
+		case row.m.Percent >= 100:
+			pct = successStyle.Render(pct)
+		case row.m.Percent >= 50:
+			pct = warnStyle.Render(pct)
+		default:
+			pct = ErrStyle.Render(pct)
+		}
+		fmt.Fprintf(w, "  %-12s %3d/%-3d %s\n", row.name, row.m.Covered, row.m.Total, pct)
+	}
+	if len(r.Uncovered) > 0 {
+		fmt.Fprintf(w, "\n%s\n", style.Render("Not covered"))
+		for _, u := range r.Uncovered {
+			fmt.Fprintf(w, "  %s\n", u)
+		}
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(w, "%s %s\n", ErrStyle.Render("error"), e)
+	}
+}
+
+var coverageHTML = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Coverage of {{.Report.Contract}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { padding: 0.3rem 1rem; text-align: left; border-bottom: 1px solid #ddd; }
+td.num { text-align: right; }
+.graph { overflow-x: auto; border: 1px solid #ddd; padding: 0.5rem; }
+.error { color: #d93025; }
+</style>
+</head>
+<body>
+<h1>Coverage of {{.Report.Contract}}</h1>
+<p>{{.Report.Fixtures}} fixture(s) and {{.Report.Tests}} test(s).</p>
+<table>
+<tr><th></th><th>Covered</th><th>Total</th><th>%</th></tr>
+{{range .Rows}}<tr><td>{{.Name}}</td><td class="num">{{.Metric.Covered}}</td><td class="num">{{.Metric.Total}}</td><td class="num">{{printf "%.1f" .Metric.Percent}}</td></tr>
+{{end}}</table>
+<div class="graph">{{.Graph}}</div>
+{{if .Report.Uncovered}}<h2>Not covered</h2>
+<ul>{{range .Report.Uncovered}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Report.Errors}}<h2>Errors</h2>
+<ul>{{range .Report.Errors}}<li class="error">{{.}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
+
+// writeCoverageHTML writes a standalone HTML report with the state graph, where covered states
+// are green, uncovered states red and transitions that never fired dashed.
+func writeCoverageHTML(w io.Writer, cv *coverage, r coverageReport) error {
+	type row struct {
+		Name   string
+		Metric coverageMetric
+	}
+	g := layoutStateGraph(cv.contract.State)
+	return coverageHTML.Execute(w, struct {
+		Report coverageReport
+		Rows   []row
+		Graph  template.HTML
+	}{
+		Report: r,
+		Rows:   []row{{"States", r.States}, {"Transitions", r.Transitions}, {"Conditions", r.Conditions}, {"Total", r.Total}},
+		// The SVG is generated from escaped state and transition names.
+		Graph: template.HTML(g.svg(graphStyle{States: cv.states, Transitions: cv.transitions})),
+	})
+}
@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/decombine/slc"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+type severity string
+
+const (
+	severityError   severity = "error"
+	severityWarning severity = "warning"
+	severityInfo    severity = "info"
+)
+
+// ruleDescriptions explains the built-in diagnostic rules for reports and documentation.
+var ruleDescriptions = map[string]string{
+	"decode/syntax":              "The contract could not be parsed.",
+	"decode/type":                "A contract field has the wrong type.",
+	"source/read":                "The contract could not be read or fetched.",
+	"source/unknown":             "The contract source is neither a file nor a URL.",
+	"source/repository":          "The Git repository of the contract source or policy could not be opened.",
+	"source/branch":              "The branch of the contract source or policy must exist.",
+	"source/path":                "The path of the contract source or policy must exist on its branch.",
+	"schema/invalid":             "The contract could not be checked against the schema.",
+	"schema/required":            "A required contract field is missing.",
+	"schema/url":                 "A contract field must be a valid URL.",
+	"state/duplicate-name":       "Each state must have a unique name.",
+	"state/undefined-initial":    "The initial state must be defined.",
+	"state/undefined-target":     "Transitions must target a defined state.",
+	"state/no-exit":              "A state with transitions must be able to leave.",
+	"state/duplicate-event":      "A transition must not handle an event that an earlier transition handles with the same or fewer conditions.",
+	"state/unreachable":          "Every state should be reachable from the initial state.",
+	"kustomization/namespace":    "Namespaces must be valid RFC 1123 labels.",
+	"kustomization/missing-spec": "A Kubernetes action must have a Kustomization spec.",
+	"kustomization/path":         "The Kustomization path must be a kustomization inside the project.",
+	"kustomization/name":         "The name prefix and suffix must produce valid Kubernetes object names.",
+	"kustomization/interval":     "The reconciliation interval must not be negative.",
+	"kustomization/conflict":     "Kustomization fields must not conflict or use unsupported values.",
+}
+
+// ruleDescription returns the description of a rule. Schema rules are named after the
+// constraints of the contract schema, so constraints without a description get a generic one.
+func ruleDescription(rule string) string {
+	if desc, ok := ruleDescriptions[rule]; ok {
+		return desc
+	}
+	if constraint, ok := strings.CutPrefix(rule, "schema/"); ok {
+		return fmt.Sprintf("A contract field must satisfy the %s constraint of the schema.", constraint)
+	}
+	return ""
+}
+
+var (
+	warnStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	infoStyle   = lipgloss.NewStyle().Foreground(contractBlue)
+	gutterStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// sourceSpan identifies the text in a contract file that a diagnostic refers to. Line and
+// Column start at 1; a zero Line means the position is unknown.
+type sourceSpan struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	Length int    `json:"length,omitempty"`
+}
+
+func (s sourceSpan) String() string {
+	if s.Line == 0 {
+		return s.File
+	}
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
+
+// diagnostic is a single finding about a Smart Legal Contract.
+type diagnostic struct {
+	Severity severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+	// Field is the Go struct path of the offending field, e.g. State.States[0].Transitions[1].To.
+	Field string     `json:"field,omitempty"`
+	Span  sourceSpan `json:"span"`
+}
+
+// hasErrors reports whether any of the diagnostics is an error.
+func hasErrors(diags []diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+// pathSegment is one key of a path into a contract document, with an optional index into
+// a sequence.
+type pathSegment struct {
+	Key   string
+	Index int
+}
+
+// documentPath translates a Go struct path into the keys used by the given format, following
+// the same tag rules as the decoders.
+func documentPath(field, format string) []pathSegment {
+	var segments []pathSegment
+	t := reflect.TypeOf(slc.Contract{})
+	for _, part := range strings.Split(field, ".") {
+		if part == "" {
+			continue
+		}
+		seg := pathSegment{Key: part, Index: -1}
+		if open := strings.Index(part, "["); open >= 0 && strings.HasSuffix(part, "]") {
+			if i, err := strconv.Atoi(part[open+1 : len(part)-1]); err == nil {
+				seg.Index = i
+			}
+			seg.Key = part[:open]
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(seg.Key); ok {
+				seg.Key = fieldKey(f, format)
+				t = f.Type
+				for t.Kind() == reflect.Ptr {
+					t = t.Elem()
+				}
+				if seg.Index >= 0 && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+					t = t.Elem()
+				}
+			} else {
+				t = nil
+			}
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// fieldKey returns the document key of a struct field in the given format.
+func fieldKey(f reflect.StructField, format string) string {
+	tags := []string{format}
+	if format == "yaml" {
+		// goccy/go-yaml falls back to the json tag when a yaml tag is absent.
+		tags = append(tags, "json")
+	}
+	for _, tag := range tags {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	if format == "yaml" {
+		return strings.ToLower(f.Name)
+	}
+	return f.Name
+}
+
+// locateDiagnostics resolves the source position of each diagnostic's field within data.
+func locateDiagnostics(name string, data []byte, format string, diags []diagnostic) {
+	var positions map[string]sourceSpan
+	var file *ast.File
+	switch format {
+	case "toml":
+		positions = tomlPositions(data)
+	default:
+		file, _ = parser.ParseBytes(data, 0)
+	}
+	for i := range diags {
+		if diags[i].Span.File == "" {
+			diags[i].Span.File = name
+		}
+		if diags[i].Span.Line != 0 || diags[i].Field == "" {
+			continue
+		}
+		path := documentPath(diags[i].Field, format)
+		var span sourceSpan
+		if format == "toml" {
+			span = lookupTOML(positions, path)
+		} else if file != nil && len(file.Docs) > 0 {
+			span = locateYAMLNode(file.Docs[0].Body, path)
+		}
+		span.File = diags[i].Span.File
+		diags[i].Span = span
+	}
+}
+
+// locateYAMLNode walks a YAML (or JSON) AST along path and returns the span of the deepest
+// node that exists, so missing fields point at their parent.
+func locateYAMLNode(node ast.Node, path []pathSegment) sourceSpan {
+	best := yamlNodeSpan(node)
+	// A field missing from the top level has no parent to point at.
+	best.Length = 0
+	for _, seg := range path {
+		value, key := yamlMapValue(node, seg.Key)
+		if key == nil {
+			return best
+		}
+		best = yamlTokenSpan(key.GetToken())
+		if value == nil {
+			return best
+		}
+		node = unwrapYAMLNode(value)
+		if seg.Index >= 0 {
+			seq, ok := node.(*ast.SequenceNode)
+			if !ok || seg.Index >= len(seq.Values) {
+				return best
+			}
+			node = unwrapYAMLNode(seq.Values[seg.Index])
+			best = yamlNodeSpan(node)
+			continue
+		}
+		if _, ok := node.(ast.ScalarNode); ok {
+			best = yamlNodeSpan(node)
+		}
+	}
+	return best
+}
+
+func unwrapYAMLNode(node ast.Node) ast.Node {
+	for {
+		switch n := node.(type) {
+		case *ast.TagNode:
+			node = n.Value
+		case *ast.AnchorNode:
+			node = n.Value
+		default:
+			return node
+		}
+	}
+}
+
+// yamlMapValue returns the value and key nodes of key within a mapping node.
+func yamlMapValue(node ast.Node, key string) (ast.Node, ast.MapKeyNode) {
+	var values []*ast.MappingValueNode
+	switch n := unwrapYAMLNode(node).(type) {
+	case *ast.MappingNode:
+		values = n.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{n}
+	}
+	var folded *ast.MappingValueNode
+	for _, mv := range values {
+		k := strings.Trim(mv.Key.GetToken().Value, `"'`)
+		if k == key {
+			return mv.Value, mv.Key
+		}
+		if folded == nil && strings.EqualFold(k, key) {
+			folded = mv
+		}
+	}
+	if folded != nil {
+		return folded.Value, folded.Key
+	}
+	return nil, nil
+}
+
+func yamlNodeSpan(node ast.Node) sourceSpan {
+	switch n := node.(type) {
+	case nil:
+		return sourceSpan{}
+	case *ast.MappingNode:
+		if len(n.Values) > 0 {
+			return yamlTokenSpan(n.Values[0].Key.GetToken())
+		}
+	case *ast.MappingValueNode:
+		return yamlTokenSpan(n.Key.GetToken())
+	case *ast.SequenceNode:
+		if len(n.Values) > 0 {
+			return yamlNodeSpan(n.Values[0])
+		}
+	}
+	return yamlTokenSpan(node.GetToken())
+}
+
+func yamlTokenSpan(tk *token.Token) sourceSpan {
+	if tk == nil || tk.Position == nil {
+		return sourceSpan{}
+	}
+	length := len(tk.Value)
+	switch tk.Type {
+	case token.DoubleQuoteType, token.SingleQuoteType:
+		length += 2
+	}
+	if length == 0 {
+		length = 1
+	}
+	return sourceSpan{Line: tk.Position.Line, Column: tk.Position.Column, Length: length}
+}
+
+// tomlPositions indexes the position of every table header and key in a TOML document by
+// its lower-cased dotted path, e.g. state.states[0].transitions[1].to.
+func tomlPositions(data []byte) map[string]sourceSpan {
+	positions := make(map[string]sourceSpan)
+	arrays := make(map[string]int)
+	table := ""
+
+	resolve := func(parts []string, array bool) string {
+		resolved := ""
+		for i, p := range parts {
+			candidate := joinTOMLPath(resolved, p)
+			last := i == len(parts)-1
+			if last && array {
+				arrays[candidate]++
+				return fmt.Sprintf("%s[%d]", candidate, arrays[candidate]-1)
+			}
+			if n, ok := arrays[candidate]; ok {
+				resolved = fmt.Sprintf("%s[%d]", candidate, n-1)
+				continue
+			}
+			resolved = candidate
+		}
+		return resolved
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		col := strings.Index(line, trimmed) + 1
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			end := strings.Index(trimmed, "]]")
+			if end < 0 {
+				continue
+			}
+			table = resolve(splitTOMLKey(trimmed[2:end]), true)
+			positions[table] = sourceSpan{Line: i + 1, Column: col, Length: end + 2}
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = resolve(splitTOMLKey(trimmed[1:end]), false)
+			positions[table] = sourceSpan{Line: i + 1, Column: col, Length: end + 1}
+		default:
+			key, value, ok := strings.Cut(trimmed, "=")
+			if !ok {
+				continue
+			}
+			path := table
+			for _, p := range splitTOMLKey(key) {
+				path = joinTOMLPath(path, p)
+			}
+			value = strings.TrimSpace(value)
+			valueCol := strings.LastIndex(line, value) + 1
+			if value == "" {
+				valueCol, value = col, strings.TrimSpace(key)
+			}
+			positions[path] = sourceSpan{Line: i + 1, Column: valueCol, Length: len(value)}
+		}
+	}
+	return positions
+}
+
+func splitTOMLKey(key string) []string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.Trim(strings.TrimSpace(p), `"'`))
+	}
+	return parts
+}
+
+func joinTOMLPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// lookupTOML returns the position of the deepest element of path present in positions.
+func lookupTOML(positions map[string]sourceSpan, path []pathSegment) sourceSpan {
+	keys := make([]string, len(path))
+	for i, seg := range path {
+		keys[i] = strings.ToLower(seg.Key)
+		if seg.Index >= 0 {
+			keys[i] += fmt.Sprintf("[%d]", seg.Index)
+		}
+	}
+	for n := len(keys); n > 0; n-- {
+		if span, ok := positions[strings.Join(keys[:n], ".")]; ok {
+			return span
+		}
+	}
+	return sourceSpan{}
+}
+
+// decodeDiagnostic converts a decoding error into a diagnostic, keeping the position reported
+// by the decoder when there is one.
+func decodeDiagnostic(name string, data []byte, err error) diagnostic {
+	d := diagnostic{
+		Severity: severityError,
+		Rule:     "decode/syntax",
+		Message:  err.Error(),
+		Span:     sourceSpan{File: name},
+	}
+
+	var yamlErr yaml.Error
+	var jsonSyntax *json.SyntaxError
+	var jsonType *json.UnmarshalTypeError
+	var tomlErr toml.ParseError
+	switch {
+	case errors.As(err, &yamlErr):
+		d.Message = yamlErr.GetMessage()
+		d.Span = yamlTokenSpan(yamlErr.GetToken())
+	case errors.As(err, &jsonSyntax):
+		d.Message = jsonSyntax.Error()
+		d.Span = offsetSpan(data, jsonSyntax.Offset)
+	case errors.As(err, &jsonType):
+		d.Rule = "decode/type"
+		d.Message = fmt.Sprintf("%s must be %s, not %s", jsonType.Field, jsonType.Type, jsonType.Value)
+		d.Span = offsetSpan(data, jsonType.Offset)
+	case errors.As(err, &tomlErr):
+		d.Message = tomlErr.Message
+		d.Span = sourceSpan{Line: tomlErr.Position.Line, Column: tomlErr.Position.Col, Length: tomlErr.Position.Len}
+	}
+	d.Span.File = name
+	return d
+}
+
+// offsetSpan converts a byte offset into a line and column.
+func offsetSpan(data []byte, offset int64) sourceSpan {
+	if offset <= 0 || offset > int64(len(data)) {
+		return sourceSpan{}
+	}
+	before := data[:offset]
+	line := strings.Count(string(before), "\n") + 1
+	col := int(offset) - strings.LastIndex(string(before), "\n")
+	if col > 1 {
+		// Decoders report the offset just past the offending byte.
+		col--
+	}
+	return sourceSpan{Line: line, Column: col, Length: 1}
+}
+
+// printDiagnostics writes each diagnostic with its location and the offending source line
+// underlined.
+func printDiagnostics(w io.Writer, data []byte, diags []diagnostic) {
+	lines := strings.Split(string(data), "\n")
+	for _, d := range diags {
+		sev := severityStyle(d.Severity)
+		fmt.Fprintf(w, "%s: %s\n", sev.Bold(true).Render(fmt.Sprintf("%s[%s]", d.Severity, d.Rule)), d.Message)
+		fmt.Fprintf(w, "  %s %s\n", gutterStyle.Render("-->"), d.Span)
+		if d.Span.Line == 0 || d.Span.Line > len(lines) {
+			if d.Field != "" {
+				fmt.Fprintf(w, "  %s %s\n", gutterStyle.Render("at"), d.Field)
+			}
+			continue
+		}
+
+		src := strings.TrimRight(lines[d.Span.Line-1], "\r")
+		num := strconv.Itoa(d.Span.Line)
+		pad := strings.Repeat(" ", len(num))
+		fmt.Fprintf(w, "%s %s\n", pad, gutterStyle.Render("|"))
+		fmt.Fprintf(w, "%s %s %s\n", gutterStyle.Render(num), gutterStyle.Render("|"), src)
+
+		// Keep tabs in the indentation so the carets line up with the source.
+		var indent strings.Builder
+		for i := 0; i < d.Span.Column-1 && i < len(src); i++ {
+			if src[i] == '\t' {
+				indent.WriteByte('\t')
+			} else {
+				indent.WriteByte(' ')
+			}
+		}
+		length := d.Span.Length
+		if length < 1 {
+			length = 1
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", pad, gutterStyle.Render("|"), indent.String(), sev.Render(strings.Repeat("^", length)))
+	}
+}
+
+func severityStyle(s severity) lipgloss.Style {
+	switch s {
+	case severityWarning:
+		return warnStyle
+	case severityInfo:
+		return infoStyle
+	}
+	return ErrStyle
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocumentPath(t *testing.T) {
+	tests := []struct {
+		field  string
+		format string
+		want   []pathSegment
+	}{
+		{
+			field:  "State.States[1].Transitions[0].To",
+			format: "json",
+			want:   []pathSegment{{Key: "state", Index: -1}, {Key: "states", Index: 1}, {Key: "transitions", Index: 0}, {Key: "to", Index: -1}},
+		},
+		{
+			field:  "State.States[0].Entry.KubernetesActions[0].KustomizationSpec.Path",
+			format: "yaml",
+			want: []pathSegment{
+				{Key: "state", Index: -1}, {Key: "states", Index: 0}, {Key: "entry", Index: -1},
+				{Key: "kubernetesActions", Index: 0}, {Key: "kustomizationSpec", Index: -1}, {Key: "path", Index: -1},
+			},
+		},
+		{field: "Unknown.Field", format: "toml", want: []pathSegment{{Key: "Unknown", Index: -1}, {Key: "Field", Index: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := documentPath(tt.field, tt.format); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("documentPath(%s, %s) = %+v, want %+v", tt.field, tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocateDiagnostics(t *testing.T) {
+	const yamlDoc = `name: c
+state:
+  initial: Draft
+  states:
+    - name: Draft
+      transitions:
+        - name: Signing
+          to: "Nowhere"
+    - name: Signed
+`
+	const jsonDoc = `{
+  "name": "c",
+  "state": {
+    "initial": "Draft",
+    "states": [
+      {"name": "Draft", "transitions": [{"name": "Signing", "to": "Nowhere"}]}
+    ]
+  }
+}`
+	const tomlDoc = `name = "c"
+
+[state]
+initial = "Draft"
+
+[[state.states]]
+name = "Draft"
+
+  [[state.states.transitions]]
+  name = "Signing"
+  to = "Nowhere"
+
+[[state.states]]
+name = "Signed"
+`
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		field  string
+		want   sourceSpan
+	}{
+		{name: "yaml quoted scalar", format: "yaml", data: yamlDoc, field: "State.States[0].Transitions[0].To", want: sourceSpan{Line: 8, Column: 15, Length: 9}},
+		{name: "yaml sequence element", format: "yaml", data: yamlDoc, field: "State.States[1]", want: sourceSpan{Line: 9, Column: 7, Length: 4}},
+		{name: "yaml missing field points at its parent", format: "yaml", data: yamlDoc, field: "State.States[1].Exit", want: sourceSpan{Line: 9, Column: 7, Length: 4}},
+		{name: "yaml missing top-level field", format: "yaml", data: yamlDoc, field: "Version", want: sourceSpan{Line: 1, Column: 1}},
+		{name: "json", format: "json", data: jsonDoc, field: "State.States[0].Transitions[0].To", want: sourceSpan{Line: 6, Column: 67, Length: 9}},
+		{name: "toml key", format: "toml", data: tomlDoc, field: "State.States[0].Transitions[0].To", want: sourceSpan{Line: 11, Column: 8, Length: 9}},
+		{name: "toml array of tables", format: "toml", data: tomlDoc, field: "State.States[1]", want: sourceSpan{Line: 13, Column: 1, Length: 16}},
+		{name: "toml missing field", format: "toml", data: tomlDoc, field: "State.URL", want: sourceSpan{Line: 3, Column: 1, Length: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := []diagnostic{{Field: tt.field}}
+			locateDiagnostics("c."+tt.format, []byte(tt.data), tt.format, diags)
+			want := tt.want
+			want.File = "c." + tt.format
+			if diags[0].Span != want {
+				t.Errorf("locateDiagnostics() span = %+v, want %+v", diags[0].Span, want)
+			}
+		})
+	}
+
+	// Positions that are already known are kept.
+	diags := []diagnostic{{Field: "Name", Span: sourceSpan{File: "other", Line: 7, Column: 2}}}
+	locateDiagnostics("c.yaml", []byte(yamlDoc), "yaml", diags)
+	if diags[0].Span != (sourceSpan{File: "other", Line: 7, Column: 2}) {
+		t.Errorf("locateDiagnostics() moved a known position to %+v", diags[0].Span)
+	}
+}
+
+func TestDecodeDiagnostic(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		rule   string
+		line   int
+		column int
+	}{
+		{name: "json syntax", format: "json", data: "{\n  \"name\": \"c\",\n}", rule: "decode/syntax", line: 3, column: 1},
+		{name: "json type", format: "json", data: "{\n  \"name\": 1\n}", rule: "decode/type", line: 2, column: 11},
+		{name: "yaml syntax", format: "yaml", data: "name: c\nstate: [\n", rule: "decode/syntax", line: 2},
+		{name: "toml syntax", format: "toml", data: "name = \"c\"\nstate = \n", rule: "decode/syntax", line: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeContract([]byte(tt.data), tt.format)
+			if err == nil {
+				t.Fatal("decodeContract() succeeded")
+			}
+			d := decodeDiagnostic("c", []byte(tt.data), err)
+			if d.Rule != tt.rule || d.Span.File != "c" || d.Span.Line != tt.line {
+				t.Errorf("decodeDiagnostic() = %s at %+v, want %s on line %d", d.Rule, d.Span, tt.rule, tt.line)
+			}
+			if tt.column != 0 && d.Span.Column != tt.column {
+				t.Errorf("decodeDiagnostic() column = %d, want %d", d.Span.Column, tt.column)
+			}
+		})
+	}
+}
+
+func TestRuleDescription(t *testing.T) {
+	if got := ruleDescription("state/no-exit"); got != ruleDescriptions["state/no-exit"] {
+		t.Errorf("ruleDescription(state/no-exit) = %q", got)
+	}
+	if got := ruleDescription("schema/oneof"); got != "A contract field must satisfy the oneof constraint of the schema." {
+		t.Errorf("ruleDescription(schema/oneof) = %q", got)
+	}
+	if got := ruleDescription("custom/rule"); got != "" {
+		t.Errorf("ruleDescription(custom/rule) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+var ErrDiagramFormatNotSupported = errors.New("diagram format not supported")
+
+// diagramWriters maps each supported --format to the function that renders it.
+var diagramWriters = map[string]func(io.Writer, *slc.Contract) error{
+	"dot":      writeDOT,
+	"mermaid":  writeMermaid,
+	"plantuml": writePlantUML,
+	"scxml":    writeSCXML,
+}
+
+// diagramExtensions infers the format of a diagram from the extension of its file.
+var diagramExtensions = map[string]string{
+	".dot":   "dot",
+	".gv":    "dot",
+	".mmd":   "mermaid",
+	".puml":  "plantuml",
+	".scxml": "scxml",
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringP("format", "f", "", "The diagram format. Options: dot, mermaid, plantuml, scxml (default dot, or inferred from --file)")
+	graphCmd.Flags().String("file", "", "Write the diagram to this file instead of stdout")
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph CONTRACT",
+	Short: "Export the state diagram of a Smart Legal Contract",
+	Long: `Export the state machine of a Smart Legal Contract as a diagram.
+
+States are nodes and transitions are edges labeled with their event and conditions. The initial state is
+marked, states without transitions are drawn as final states, and states with Entry or Exit actions are
+highlighted. Diagrams are written in Graphviz DOT, Mermaid, PlantUML or SCXML.`,
+	Example: `  contract graph contract.yaml --format mermaid
+  contract graph contract.yaml --file docs/states.puml`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		if format == "" {
+			format = "dot"
+			if f, ok := diagramExtensions[strings.ToLower(filepath.Ext(file))]; ok {
+				format = f
+			}
+		}
+		write, ok := diagramWriters[format]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrDiagramFormatNotSupported, format)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		if file == "" || file == "-" {
+			return write(cmd.OutOrStdout(), c)
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if err := write(f, c); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	},
+}
+
+// hasActions reports whether a state declares an Entry or Exit action.
+func hasActions(s slc.State) bool {
+	return len(describeAction(s.Entry))+len(describeAction(s.Exit)) > 0
+}
+
+// conditionGuard renders the conditions of a transition as a guard, e.g.
+// [data.signature.validated = true].
+func conditionGuard(t slc.Transition) string {
+	if len(t.Conditions) == 0 {
+		return ""
+	}
+	parts := make([]string, len(t.Conditions))
+	for i, c := range t.Conditions {
+		parts[i] = fmt.Sprintf("%s = %s", c.Name, c.Value)
+	}
+	return "[" + strings.Join(parts, " and ") + "]"
+}
+
+// edgeLabel returns the lines of a transition's label: its event and its guard.
+func edgeLabel(t slc.Transition) []string {
+	lines := []string{t.On}
+	if guard := conditionGuard(t); guard != "" {
+		lines = append(lines, guard)
+	}
+	return lines
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func writeDOT(w io.Writer, c *slc.Contract) error {
+	g := layoutStateGraph(c.State)
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(c.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"sans-serif\", color=\"#227BF0\"];\n")
+	b.WriteString("  edge [fontname=\"sans-serif\", fontsize=10];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{}
+		if hasActions(c.State.States[n.State]) {
+			attrs = append(attrs, `style="rounded,filled"`, `fillcolor="#E8F1FE"`)
+		}
+		if n.Initial {
+			attrs = append(attrs, "penwidth=2")
+		}
+		if n.Terminal {
+			attrs = append(attrs, "peripheries=2")
+		}
+		fmt.Fprintf(&b, "  %s", dotQuote(n.Name))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	for _, n := range g.Nodes {
+		if n.Initial {
+			b.WriteString("  __initial [shape=point, width=0.15];\n")
+			fmt.Fprintf(&b, "  __initial -> %s;\n", dotQuote(n.Name))
+		}
+	}
+	for _, e := range g.Edges {
+		t := c.State.States[e.State].Transitions[e.Transition]
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(g.Nodes[e.From].Name), dotQuote(g.Nodes[e.To].Name), dotQuote(strings.Join(edgeLabel(t), "\n")))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidText escapes characters that end or break a Mermaid label.
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", ";", "#59;", "\n", "<br>").Replace(s)
+}
+
+func writeMermaid(w io.Writer, c *slc.Contract) error {
+	g := layoutStateGraph(c.State)
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	b.WriteString("  classDef action fill:#E8F1FE,stroke:#227BF0\n")
+	for i, n := range g.Nodes {
+		fmt.Fprintf(&b, "  state \"%s\" as s%d\n", mermaidText(n.Name), i)
+	}
+	for i, n := range g.Nodes {
+		if n.Initial {
+			fmt.Fprintf(&b, "  [*] --> s%d\n", i)
+		}
+	}
+	for _, e := range g.Edges {
+		t := c.State.States[e.State].Transitions[e.Transition]
+		fmt.Fprintf(&b, "  s%d --> s%d : %s\n", e.From, e.To, mermaidText(strings.Join(edgeLabel(t), "\n")))
+	}
+	for i, n := range g.Nodes {
+		if n.Terminal {
+			fmt.Fprintf(&b, "  s%d --> [*]\n", i)
+		}
+	}
+	for i, n := range g.Nodes {
+		if hasActions(c.State.States[n.State]) {
+			fmt.Fprintf(&b, "  class s%d action\n", i)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePlantUML(w io.Writer, c *slc.Contract) error {
+	g := layoutStateGraph(c.State)
+	var b strings.Builder
+	fmt.Fprintf(&b, "@startuml\ntitle %s\n", c.Name)
+	b.WriteString("skinparam state {\n  BorderColor #227BF0\n  BackgroundColor<<action>> #E8F1FE\n}\n")
+	for i, n := range g.Nodes {
+		s := c.State.States[n.State]
+		fmt.Fprintf(&b, "state %s as s%d", dotQuote(n.Name), i)
+		if hasActions(s) {
+			b.WriteString(" <<action>>")
+		}
+		b.WriteString("\n")
+		for _, a := range describeAction(s.Entry) {
+			fmt.Fprintf(&b, "s%d : entry / %s\n", i, a)
+		}
+		for _, a := range describeAction(s.Exit) {
+			fmt.Fprintf(&b, "s%d : exit / %s\n", i, a)
+		}
+	}
+	for i, n := range g.Nodes {
+		if n.Initial {
+			fmt.Fprintf(&b, "[*] --> s%d\n", i)
+		}
+	}
+	for _, e := range g.Edges {
+		t := c.State.States[e.State].Transitions[e.Transition]
+		fmt.Fprintf(&b, "s%d --> s%d : %s\n", e.From, e.To, strings.Join(edgeLabel(t), `\n`))
+	}
+	for i, n := range g.Nodes {
+		if n.Terminal {
+			fmt.Fprintf(&b, "s%d --> [*]\n", i)
+		}
+	}
+	b.WriteString("@enduml\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scxmlID matches the characters SCXML does not allow in state IDs.
+var scxmlID = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+type scxmlDocument struct {
+	XMLName   xml.Name     `xml:"scxml"`
+	Namespace string       `xml:"xmlns,attr"`
+	Version   string       `xml:"version,attr"`
+	Name      string       `xml:"name,attr,omitempty"`
+	Initial   string       `xml:"initial,attr,omitempty"`
+	Datamodel string       `xml:"datamodel,attr"`
+	Comment   string       `xml:",comment"`
+	States    []scxmlState `xml:"state"`
+	Finals    []scxmlState `xml:"final"`
+}
+
+type scxmlState struct {
+	ID          string            `xml:"id,attr"`
+	OnEntry     *scxmlExecutable  `xml:"onentry,omitempty"`
+	OnExit      *scxmlExecutable  `xml:"onexit,omitempty"`
+	Transitions []scxmlTransition `xml:"transition"`
+}
+
+type scxmlExecutable struct {
+	Logs []scxmlLog `xml:"log"`
+}
+
+type scxmlLog struct {
+	Label string `xml:"label,attr"`
+	Expr  string `xml:"expr,attr"`
+}
+
+type scxmlTransition struct {
+	Event  string `xml:"event,attr,omitempty"`
+	Cond   string `xml:"cond,attr,omitempty"`
+	Target string `xml:"target,attr"`
+}
+
+// writeSCXML writes the state machine in the ECMAScript data model. The data of each SCXML event
+// is the CloudEvent, so a condition on data.signature.validated tests
+// _event.data.data.signature.validated. Actions are logged rather than performed.
+func writeSCXML(w io.Writer, c *slc.Contract) error {
+	g := layoutStateGraph(c.State)
+	ids := make([]string, len(g.Nodes))
+	used := make(map[string]bool)
+	for i, n := range g.Nodes {
+		id := strings.Trim(scxmlID.ReplaceAllString(n.Name, "_"), "_")
+		if id == "" || !(id[0] == '_' || id[0] >= 'A' && id[0] <= 'Z' || id[0] >= 'a' && id[0] <= 'z') {
+			id = "s_" + id
+		}
+		for base, k := id, 2; used[id]; k++ {
+			id = fmt.Sprintf("%s_%d", base, k)
+		}
+		used[id] = true
+		ids[i] = id
+	}
+
+	doc := scxmlDocument{
+		Namespace: "http://www.w3.org/2005/07/scxml",
+		Version:   "1.0",
+		Name:      c.Name,
+		Datamodel: "ecmascript",
+		Comment:   " The data of each event is the CloudEvent the contract receives. ",
+	}
+	for i, n := range g.Nodes {
+		s := c.State.States[n.State]
+		st := scxmlState{ID: ids[i], OnEntry: scxmlActions("entry", s.Entry), OnExit: scxmlActions("exit", s.Exit)}
+		if n.Initial {
+			doc.Initial = ids[i]
+		}
+		for _, e := range g.Edges {
+			if e.From != i {
+				continue
+			}
+			t := s.Transitions[e.Transition]
+			st.Transitions = append(st.Transitions, scxmlTransition{Event: t.On, Cond: scxmlCondition(t.Conditions), Target: ids[e.To]})
+		}
+		if n.Terminal {
+			doc.Finals = append(doc.Finals, st)
+		} else {
+			doc.States = append(doc.States, st)
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func scxmlActions(label string, a slc.Action) *scxmlExecutable {
+	actions := describeAction(a)
+	if len(actions) == 0 {
+		return nil
+	}
+	x := &scxmlExecutable{}
+	for _, action := range actions {
+		expr, _ := json.Marshal(action)
+		x.Logs = append(x.Logs, scxmlLog{Label: label, Expr: string(expr)})
+	}
+	return x
+}
+
+// scxmlCondition translates conditions to an ECMAScript expression. Paths are written with
+// bracket notation so keys such as party-a stay valid. Booleans and numbers are compared as
+// such; other values as strings.
+func scxmlCondition(conditions []slc.Condition) string {
+	var parts []string
+	for _, c := range conditions {
+		value := strconv.Quote(c.Value)
+		var v interface{}
+		if err := json.Unmarshal([]byte(c.Value), &v); err == nil {
+			switch v.(type) {
+			case bool, float64:
+				value = strings.TrimSpace(c.Value)
+			}
+		}
+		var path strings.Builder
+		path.WriteString("_event.data")
+		for _, seg := range strings.Split(c.Name, ".") {
+			fmt.Fprintf(&path, "[%s]", strconv.Quote(seg))
+		}
+		parts = append(parts, fmt.Sprintf("%s == %s", path.String(), value))
+	}
+	return strings.Join(parts, " && ")
+}
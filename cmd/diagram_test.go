@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func TestSCXMLCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []slc.Condition
+		want       string
+	}{
+		{name: "none", want: ""},
+		{
+			name:       "string",
+			conditions: []slc.Condition{{Name: "data.signature.signer", Value: "alice"}},
+			want:       `_event.data["data"]["signature"]["signer"] == "alice"`,
+		},
+		{
+			name:       "boolean and number",
+			conditions: []slc.Condition{{Name: "data.validated", Value: "true"}, {Name: "data.amount", Value: " 12.5 "}},
+			want:       `_event.data["data"]["validated"] == true && _event.data["data"]["amount"] == 12.5`,
+		},
+		{
+			name:       "keys that are not identifiers",
+			conditions: []slc.Condition{{Name: "data.party-a.2nd", Value: `say "yes"`}},
+			want:       `_event.data["data"]["party-a"]["2nd"] == "say \"yes\""`,
+		},
+		{
+			name:       "JSON that is neither boolean nor number",
+			conditions: []slc.Condition{{Name: "type", Value: "null"}},
+			want:       `_event.data["type"] == "null"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scxmlCondition(tt.conditions); got != tt.want {
+				t.Errorf("scxmlCondition() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSCXML(t *testing.T) {
+	c := &slc.Contract{Name: "Test", State: slc.StateConfiguration{Initial: "In Draft", States: []slc.State{
+		{Name: "In Draft", Transitions: []slc.Transition{
+			{Name: "Signing", To: "1 Signed", On: "sign", Conditions: []slc.Condition{{Name: "data.party-a", Value: "a&b"}}},
+		}},
+		{Name: "1 Signed"},
+	}}}
+	var buf bytes.Buffer
+	if err := writeSCXML(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	var doc scxmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("writeSCXML() wrote invalid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Initial != "In_Draft" || len(doc.States) != 1 || len(doc.Finals) != 1 || doc.Finals[0].ID != "s_1_Signed" {
+		t.Fatalf("writeSCXML() = %+v", doc)
+	}
+	want := scxmlTransition{Event: "sign", Cond: `_event.data["data"]["party-a"] == "a&b"`, Target: "s_1_Signed"}
+	if got := doc.States[0].Transitions; len(got) != 1 || got[0] != want {
+		t.Errorf("writeSCXML() transitions = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json, markdown")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD [NEW]",
+	Short: "Compare two versions of a Smart Legal Contract",
+	Long: `Compare two versions of a Smart Legal Contract by their state machines rather than their text.
+
+diff reports states that were added, removed or renamed, transitions whose event, target or conditions changed,
+changes to Entry and Exit actions and changes to the contract's metadata. A state is reported as renamed when a
+removed and an added state have mostly the same transitions and actions.
+
+Each version is a file, a URL or a Git revision and path such as main:contracts/contract.yaml. With a single
+file, the file is compared with its version at HEAD. The markdown format is meant for pull request comments.`,
+	Example: `  contract diff contract.yaml
+  contract diff v1.0.0:contract.yaml contract.yaml -o markdown`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" && output != "markdown" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+		if len(args) == 1 {
+			args = []string{headRevision(args[0]), args[0]}
+		}
+		a, err := loadContractVersion(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := loadContractVersion(args[1])
+		if err != nil {
+			return err
+		}
+
+		r := diffReport{Old: args[0], New: args[1], Changes: diffContracts(a, b)}
+		if r.Changes == nil {
+			r.Changes = []contractChange{}
+		}
+		switch output {
+		case "json":
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		case "markdown":
+			writeMarkdownDiff(cmd.OutOrStdout(), r)
+		default:
+			printDiffReport(cmd.OutOrStdout(), r)
+		}
+		return nil
+	},
+}
+
+type diffReport struct {
+	Old     string           `json:"old"`
+	New     string           `json:"new"`
+	Changes []contractChange `json:"changes"`
+}
+
+// headRevision returns the Git revision of a file at HEAD, relative to the current directory.
+func headRevision(file string) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(wd, file); err == nil {
+			file = rel
+		}
+	}
+	return "HEAD:./" + filepath.ToSlash(filepath.Clean(file))
+}
+
+// loadContractVersion loads a contract from a file, a URL or a Git revision and path written
+// as REV:PATH.
+func loadContractVersion(input string) (*slc.Contract, error) {
+	if _, err := os.Stat(input); err == nil || isContractURL(input) {
+		return loadContract(input)
+	}
+	rev, file, ok := strings.Cut(input, ":")
+	// A single letter before the colon is a Windows drive.
+	if !ok || len(rev) < 2 || file == "" {
+		return loadContract(input)
+	}
+	// A revision that starts with a dash must not be read as an option.
+	data, err := gitOutput("", "show", "--end-of-options", rev+":"+file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", input, err)
+	}
+	return decodeContract(data, contractFormat(file, data))
+}
+
+func printDiffReport(w io.Writer, r diffReport) {
+	fmt.Fprintf(w, "%s %s %s %s\n", style.Render("Comparing"), r.Old, helpStyle("─▶"), r.New)
+	if len(r.Changes) == 0 {
+		fmt.Fprintf(w, "\n%s no semantic changes\n", successStyle.Render("✓"))
+		return
+	}
+	for _, section := range changeSections {
+		var changes []contractChange
+		for _, c := range r.Changes {
+			if c.section() == section {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "\n"+style.Render(section))
+		for _, c := range changes {
+			sign := warnStyle.Render(c.sign())
+			switch c.sign() {
+			case "+":
+				sign = successStyle.Render(c.sign())
+			case "-":
+				sign = ErrStyle.Render(c.sign())
+			}
+			fmt.Fprintf(w, "  %s %s\n", sign, c.Message)
+		}
+	}
+	fmt.Fprintf(w, "\n%d change(s)\n", len(r.Changes))
+}
+
+func writeMarkdownDiff(w io.Writer, r diffReport) {
+	fmt.Fprintf(w, "### Contract changes\n\n`%s` → `%s`\n", r.Old, r.New)
+	if len(r.Changes) == 0 {
+		fmt.Fprint(w, "\nNo semantic changes.\n")
+		return
+	}
+	for _, section := range changeSections {
+		first := true
+		for _, c := range r.Changes {
+			if c.section() != section {
+				continue
+			}
+			if first {
+				fmt.Fprintf(w, "\n#### %s\n\n", section)
+				first = false
+			}
+			fmt.Fprintf(w, "- `%s` %s\n", c.sign(), markdownText(c.Message))
+		}
+	}
+}
+
+// markdownText escapes the characters that Markdown would treat as formatting.
+func markdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "|", `\|`).Replace(s)
+}
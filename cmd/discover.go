@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// contractFileNames are the file names recognized as Smart Legal Contracts when searching
+// directories.
+var contractFileNames = map[string]bool{
+	"contract.json": true,
+	"contract.yaml": true,
+	"contract.yml":  true,
+	"contract.toml": true,
+}
+
+// expandInputs resolves directories and glob patterns into individual contract files. URLs
+// and plain files are passed through unchanged. Inputs that cannot be resolved are returned
+// as failed results so they are still reported.
+func expandInputs(inputs []string) ([]string, []contractResult) {
+	var targets []string
+	var failures []contractResult
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, in := range inputs {
+		if in == "" {
+			continue
+		}
+		if isContractURL(in) {
+			add(in)
+			continue
+		}
+
+		paths := []string{in}
+		if isGlob(in) {
+			matches, err := globContracts(in)
+			if err != nil {
+				failures = append(failures, failedResult(in, "source/read", err))
+				continue
+			}
+			if len(matches) == 0 {
+				failures = append(failures, failedResult(in, "source/read", fmt.Errorf("no contracts match pattern %s", in)))
+				continue
+			}
+			paths = matches
+		}
+
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil || !info.IsDir() {
+				// Broad patterns such as "contracts/**" also match unrelated files.
+				if p != in && !isContractMatch(p) {
+					continue
+				}
+				// Missing files are reported by validateInput.
+				add(p)
+				continue
+			}
+			found, err := findContracts(p)
+			if err != nil {
+				failures = append(failures, failedResult(p, "source/read", err))
+				continue
+			}
+			if len(found) == 0 && !isGlob(in) {
+				failures = append(failures, failedResult(p, "source/read", fmt.Errorf("no contracts found in directory %s", p)))
+			}
+			for _, f := range found {
+				add(f)
+			}
+		}
+	}
+	return targets, failures
+}
+
+// findContracts recursively searches root for contract files, skipping hidden directories.
+func findContracts(root string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if contractFileNames[strings.ToLower(d.Name())] {
+			found = append(found, path)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// isContractMatch reports whether a file matched by a glob pattern is a contract. Files in
+// tests directories and documents without a state, such as test suites, schemas and
+// Kustomizations, are skipped. Files that cannot be decoded are kept so their errors are
+// reported.
+func isContractMatch(p string) bool {
+	if !hasContractExtension(p) {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(p)), "/") {
+		if dir == "tests" {
+			return false
+		}
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return true
+	}
+	doc, err := decodeDocument(data, contractFormat(p, data))
+	if err != nil {
+		return true
+	}
+	fields, _ := doc.(yaml.MapSlice)
+	for _, item := range fields {
+		if item.Key == "state" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasContractExtension(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globContracts expands a glob pattern. In addition to the filepath.Match syntax, a "**"
+// path segment matches any number of directories.
+func globContracts(pattern string) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	segments := strings.Split(pattern, "/")
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(filepath.FromSlash(pattern))
+		// Like "**", wildcards do not descend into hidden directories.
+		var visible []string
+		for _, m := range matches {
+			if !inHiddenDir(segments, strings.Split(filepath.ToSlash(m), "/")) {
+				visible = append(visible, m)
+			}
+		}
+		return visible, err
+	}
+
+	// Walk from the longest directory prefix that contains no pattern characters.
+	var base []string
+	for _, seg := range segments {
+		if isGlob(seg) {
+			break
+		}
+		base = append(base, seg)
+	}
+	root := strings.Join(base, "/")
+	if root == "" {
+		root = "."
+	}
+	if strings.HasPrefix(pattern, "/") && root == "." {
+		root = "/"
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != filepath.FromSlash(root) && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if matchGlob(segments, strings.Split(filepath.ToSlash(p), "/")) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// inHiddenDir reports whether a match of pattern has a hidden directory that the pattern does
+// not name explicitly.
+func inHiddenDir(pattern, match []string) bool {
+	for i := 0; i < len(match)-1 && i < len(pattern); i++ {
+		if strings.HasPrefix(match[i], ".") && !strings.HasPrefix(pattern[i], ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches path segments against pattern segments, where "**" matches zero or more
+// segments.
+func matchGlob(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchGlob(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
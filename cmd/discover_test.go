@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "contracts/**", path: "contracts", want: true},
+		{pattern: "contracts/**", path: "contracts/a/b/contract.yaml", want: true},
+		{pattern: "contracts/**/contract.yaml", path: "contracts/contract.yaml", want: true},
+		{pattern: "contracts/**/contract.yaml", path: "contracts/a/b/contract.yaml", want: true},
+		{pattern: "contracts/**/contract.yaml", path: "contracts/a/b/other.yaml"},
+		{pattern: "**/*.json", path: "a/b.json", want: true},
+		{pattern: "contracts/**/b/*.toml", path: "contracts/a/b/c.toml", want: true},
+		{pattern: "contracts/**/b/*.toml", path: "contracts/a/c/c.toml"},
+		{pattern: "contracts/*/contract.yaml", path: "contracts/a/b/contract.yaml"},
+		{pattern: "contracts/*", path: "other/a"},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/")); got != tt.want {
+			t.Errorf("matchGlob(%s, %s) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		"contracts/a/contract.yaml":       "name: a\nstate:\n  initial: A\n",
+		"contracts/b/service.json":        `{"name": "b", "state": {"initial": "A"}}`,
+		"contracts/b/schema.json":         `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`,
+		"contracts/b/kustomization.yaml":  "resources: []\n",
+		"contracts/tests/suite.yaml":      "name: suite\nstate: {}\n",
+		"contracts/.hidden/contract.yaml": "name: hidden\nstate: {}\n",
+		"contracts/README.md":             "# Contracts\n",
+		"contracts/broken.yaml":           "name: [\n",
+	}
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		inputs   []string
+		want     []string
+		failures []string
+	}{
+		{name: "directory", inputs: []string{"contracts"}, want: []string{"contracts/a/contract.yaml"}},
+		{name: "any depth", inputs: []string{"contracts/**/*.json"}, want: []string{"contracts/b/service.json"}},
+		{
+			name:   "contracts only",
+			inputs: []string{"contracts/**/*.yaml"},
+			want:   []string{"contracts/a/contract.yaml", "contracts/broken.yaml"},
+		},
+		{name: "single level", inputs: []string{"contracts/*/contract.yaml"}, want: []string{"contracts/a/contract.yaml"}},
+		{name: "explicit hidden directory", inputs: []string{"contracts/.hidden/*.yaml"}, want: []string{"contracts/.hidden/contract.yaml"}},
+		{name: "explicit file", inputs: []string{"contracts/b/schema.json"}, want: []string{"contracts/b/schema.json"}},
+		{
+			name:   "duplicates and URLs",
+			inputs: []string{"contracts/a/contract.yaml", "contracts/**/contract.yaml", "https://example.com/c.json", ""},
+			want:   []string{"contracts/a/contract.yaml", "https://example.com/c.json"},
+		},
+		{name: "no matches", inputs: []string{"contracts/**/*.toml"}, failures: []string{"contracts/**/*.toml"}},
+		{name: "empty directory", inputs: []string{"contracts/b"}, failures: []string{"contracts/b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, failed := expandInputs(tt.inputs)
+			var want []string
+			for _, w := range tt.want {
+				want = append(want, filepath.FromSlash(w))
+			}
+			if !reflect.DeepEqual(targets, want) {
+				t.Errorf("expandInputs() = %q, want %q", targets, want)
+			}
+			var failures []string
+			for _, f := range failed {
+				failures = append(failures, f.Name)
+			}
+			if !reflect.DeepEqual(failures, tt.failures) {
+				t.Errorf("expandInputs() failures = %q, want %q", failures, tt.failures)
+			}
+		})
+	}
+}
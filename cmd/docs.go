@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/decombine/slc"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/spf13/cobra"
+)
+
+var ErrLanguageNotSupported = errors.New("language not supported")
+
+// docsLabels are the localized headings used by the documentation templates.
+var docsLabels = []string{
+	"DocsVersion", "DocsSource", "DocsText", "DocsPolicy", "DocsDiagram", "DocsStates", "DocsTransitions",
+	"DocsState", "DocsEntry", "DocsExit", "DocsTransition", "DocsFrom", "DocsTo", "DocsEvent", "DocsConditions",
+}
+
+func init() {
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.Flags().StringP("format", "f", "", "The output format. Options: markdown, html (default markdown, or inferred from --file)")
+	docsCmd.Flags().String("file", "", "Write the documentation to this file instead of stdout")
+	docsCmd.Flags().String("lang", "", fmt.Sprintf("The language of the documentation. Options: %s (default from the configuration file, or en)", strings.Join(langs, ", ")))
+}
+
+var docsCmd = &cobra.Command{
+	Use:   "docs CONTRACT",
+	Short: "Generate documentation for a Smart Legal Contract",
+	Long: `Generate a human-readable summary of a Smart Legal Contract in Markdown or HTML.
+
+The summary lists the contract's version, source, text and policies, a table of states with their Entry and
+Exit actions and a table of transitions with their events and conditions. Markdown embeds a Mermaid diagram
+of the state machine, which GitHub and GitLab render; HTML embeds an SVG.`,
+	Example: `  contract docs contracts/contract.yaml --file contracts/README.md
+  contract docs contracts/contract.yaml --format html --lang es`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		lang, _ := cmd.Flags().GetString("lang")
+		if format == "" {
+			format = "markdown"
+			if ext := strings.ToLower(filepath.Ext(file)); ext == ".html" || ext == ".htm" {
+				format = "html"
+			}
+		}
+		if format != "markdown" && format != "html" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, format)
+		}
+		if lang == "" {
+			lang = "en"
+			if cfg, err := Config(); err == nil && cfg.Language != "" {
+				lang = cfg.Language
+			}
+		}
+		if !slices.Contains(langs, lang) {
+			return fmt.Errorf("%w: %s", ErrLanguageNotSupported, lang)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		docs, err := newContractDocs(c, filepath.Base(args[0]), initLang(lang))
+		if err != nil {
+			return err
+		}
+		docs.Lang = lang
+
+		write := writeMarkdownDocs
+		if format == "html" {
+			write = writeHTMLDocs
+		}
+		if file == "" || file == "-" {
+			return write(cmd.OutOrStdout(), docs)
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return write(f, docs)
+	},
+}
+
+// contractDocs is the localized content of a contract's documentation.
+type contractDocs struct {
+	Lang        string
+	Labels      map[string]string
+	Contract    *slc.Contract
+	Source      string
+	Policy      string
+	Summary     string
+	States      []docsState
+	Transitions []docsTransition
+	Mermaid     string
+	SVG         template.HTML
+	Generated   string
+}
+
+type docsState struct {
+	Name  string
+	Kind  string
+	Entry []string
+	Exit  []string
+}
+
+type docsTransition struct {
+	From       string
+	Name       string
+	Event      string
+	Conditions []string
+	To         string
+}
+
+func newContractDocs(c *slc.Contract, file string, l *i18n.Localizer) (*contractDocs, error) {
+	d := &contractDocs{
+		Contract: c,
+		Labels:   make(map[string]string, len(docsLabels)),
+		Source:   describeLocation(c.Source.URL, c.Source.Branch, c.Source.Path),
+		Policy:   describeLocation(c.Policy.URL, c.Policy.Branch, c.Policy.Directory),
+	}
+	for _, id := range docsLabels {
+		d.Labels[id] = localize(l, id, nil)
+	}
+
+	g := layoutStateGraph(c.State)
+	for _, n := range g.Nodes {
+		s := c.State.States[n.State]
+		ds := docsState{Name: s.Name, Entry: localizedActions(l, s.Entry), Exit: localizedActions(l, s.Exit)}
+		switch {
+		case n.Initial:
+			ds.Kind = localize(l, "DocsInitial", nil)
+		case n.Terminal:
+			ds.Kind = localize(l, "DocsFinal", nil)
+		}
+		d.States = append(d.States, ds)
+	}
+	// Transitions to undefined states have no edge in the graph but are still documented.
+	for _, s := range c.State.States {
+		for _, t := range s.Transitions {
+			dt := docsTransition{From: s.Name, Name: t.Name, Event: t.On, To: t.To}
+			for _, cond := range t.Conditions {
+				dt.Conditions = append(dt.Conditions, fmt.Sprintf("%s = %s", cond.Name, cond.Value))
+			}
+			d.Transitions = append(d.Transitions, dt)
+		}
+	}
+	d.Summary = localize(l, "DocsSummary", map[string]interface{}{
+		"Initial":     c.State.Initial,
+		"States":      len(d.States),
+		"Transitions": len(d.Transitions),
+	})
+	d.Generated = localize(l, "DocsGenerated", map[string]string{"File": file})
+
+	var mermaid strings.Builder
+	if err := writeMermaid(&mermaid, c); err != nil {
+		return nil, err
+	}
+	d.Mermaid = mermaid.String()
+	// The SVG is generated from escaped state and transition names.
+	d.SVG = template.HTML(g.svg(graphStyle{}))
+	return d, nil
+}
+
+// describeLocation summarizes a location in a Git repository, e.g. URL (main, contracts).
+func describeLocation(url, branch, path string) string {
+	var details []string
+	for _, s := range []string{branch, path} {
+		if s != "" {
+			details = append(details, s)
+		}
+	}
+	if url == "" || len(details) == 0 {
+		return url
+	}
+	return fmt.Sprintf("%s (%s)", url, strings.Join(details, ", "))
+}
+
+// localizedActions is describeAction in the language of the localizer.
+func localizedActions(l *i18n.Localizer, a slc.Action) []string {
+	var out []string
+	for _, ka := range a.KubernetesActions {
+		ns := ka.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		if ka.KustomizationSpec == nil {
+			out = append(out, localize(l, "DocsMissingKustomization", map[string]string{"Namespace": ns}))
+			continue
+		}
+		out = append(out, localize(l, "DocsApplyKustomization", map[string]string{"Path": ka.KustomizationSpec.Path, "Namespace": ns}))
+	}
+	if len(out) == 0 && a.ActionType != "" {
+		out = append(out, a.ActionType)
+	}
+	return out
+}
+
+// markdownCell escapes text for a cell of a Markdown table.
+func markdownCell(lines []string) string {
+	if len(lines) == 0 {
+		return "—"
+	}
+	escaped := make([]string, len(lines))
+	for i, s := range lines {
+		escaped[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	}
+	return strings.Join(escaped, "<br>")
+}
+
+func writeMarkdownDocs(w io.Writer, d *contractDocs) error {
+	var b strings.Builder
+	c := d.Contract
+	fmt.Fprintf(&b, "# %s\n\n", c.Name)
+	b.WriteString("| | |\n|---|---|\n")
+	rows := [][2]string{
+		{d.Labels["DocsVersion"], c.Version},
+		{d.Labels["DocsSource"], d.Source},
+		{d.Labels["DocsText"], c.Text.URL},
+		{d.Labels["DocsPolicy"], d.Policy},
+	}
+	for _, r := range rows {
+		if r[1] != "" {
+			fmt.Fprintf(&b, "| **%s** | %s |\n", r[0], markdownCell([]string{r[1]}))
+		}
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", d.Summary)
+
+	fmt.Fprintf(&b, "## %s\n\n```mermaid\n%s```\n\n", d.Labels["DocsDiagram"], d.Mermaid)
+
+	fmt.Fprintf(&b, "## %s\n\n", d.Labels["DocsStates"])
+	fmt.Fprintf(&b, "| %s | %s | %s |\n|---|---|---|\n", d.Labels["DocsState"], d.Labels["DocsEntry"], d.Labels["DocsExit"])
+	for _, s := range d.States {
+		name := "**" + markdownCell([]string{s.Name}) + "**"
+		if s.Kind != "" {
+			name += " _(" + s.Kind + ")_"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", name, markdownCell(s.Entry), markdownCell(s.Exit))
+	}
+
+	if len(d.Transitions) > 0 {
+		fmt.Fprintf(&b, "\n## %s\n\n", d.Labels["DocsTransitions"])
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n|---|---|---|---|---|\n",
+			d.Labels["DocsFrom"], d.Labels["DocsTransition"], d.Labels["DocsEvent"], d.Labels["DocsConditions"], d.Labels["DocsTo"])
+		for _, t := range d.Transitions {
+			var name []string
+			if t.Name != "" {
+				name = []string{t.Name}
+			}
+			conditions := make([]string, len(t.Conditions))
+			for i, cond := range t.Conditions {
+				conditions[i] = "`" + strings.ReplaceAll(cond, "`", "'") + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s | %s |\n",
+				markdownCell([]string{t.From}), markdownCell(name), t.Event, markdownCell(conditions), markdownCell([]string{t.To}))
+		}
+	}
+	fmt.Fprintf(&b, "\n_%s_\n", d.Generated)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var docsHTML = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Contract.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { padding: 0.3rem 1rem; text-align: left; vertical-align: top; border-bottom: 1px solid #ddd; }
+code { font-size: 0.9em; }
+.kind { color: #666; font-style: italic; }
+.graph { overflow-x: auto; border: 1px solid #ddd; padding: 0.5rem; }
+footer { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{.Contract.Name}}</h1>
+<table>
+{{with .Contract.Version}}<tr><th>{{index $.Labels "DocsVersion"}}</th><td>{{.}}</td></tr>{{end}}
+{{with .Source}}<tr><th>{{index $.Labels "DocsSource"}}</th><td>{{.}}</td></tr>{{end}}
+{{with .Contract.Text.URL}}<tr><th>{{index $.Labels "DocsText"}}</th><td><a href="{{.}}">{{.}}</a></td></tr>{{end}}
+{{with .Policy}}<tr><th>{{index $.Labels "DocsPolicy"}}</th><td>{{.}}</td></tr>{{end}}
+</table>
+<p>{{.Summary}}</p>
+<h2>{{index .Labels "DocsDiagram"}}</h2>
+<div class="graph">{{.SVG}}</div>
+<h2>{{index .Labels "DocsStates"}}</h2>
+<table>
+<tr><th>{{index .Labels "DocsState"}}</th><th>{{index .Labels "DocsEntry"}}</th><th>{{index .Labels "DocsExit"}}</th></tr>
+{{range .States}}<tr><td><strong>{{.Name}}</strong>{{with .Kind}} <span class="kind">({{.}})</span>{{end}}</td><td>{{range $i, $a := .Entry}}{{if $i}}<br>{{end}}{{$a}}{{else}}—{{end}}</td><td>{{range $i, $a := .Exit}}{{if $i}}<br>{{end}}{{$a}}{{else}}—{{end}}</td></tr>
+{{end}}</table>
+{{if .Transitions}}<h2>{{index .Labels "DocsTransitions"}}</h2>
+<table>
+<tr><th>{{index .Labels "DocsFrom"}}</th><th>{{index .Labels "DocsTransition"}}</th><th>{{index .Labels "DocsEvent"}}</th><th>{{index .Labels "DocsConditions"}}</th><th>{{index .Labels "DocsTo"}}</th></tr>
+{{range .Transitions}}<tr><td>{{.From}}</td><td>{{with .Name}}{{.}}{{else}}—{{end}}</td><td><code>{{.Event}}</code></td><td>{{range $i, $c := .Conditions}}{{if $i}}<br>{{end}}<code>{{$c}}</code>{{else}}—{{end}}</td><td>{{.To}}</td></tr>
+{{end}}</table>{{end}}
+<footer>{{.Generated}}</footer>
+</body>
+</html>
+`))
+
+func writeHTMLDocs(w io.Writer, d *contractDocs) error {
+	return docsHTML.Execute(w, d)
+}
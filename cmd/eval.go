@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/decombine/contract/pkg/condition"
+	"github.com/spf13/cobra"
+)
+
+var ErrNoTransitionSatisfied = errors.New("the event does not satisfy any transition")
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+	evalCmd.Flags().StringP("event", "e", "", "A CloudEvent in JSON. Use - for stdin.")
+	evalCmd.Flags().String("contract", "", "The Smart Legal Contract whose conditions are evaluated")
+	evalCmd.Flags().String("state", "", "Only evaluate the transitions of this state")
+	evalCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json")
+	_ = evalCmd.MarkFlagRequired("event")
+	_ = evalCmd.MarkFlagRequired("contract")
+}
+
+var evalCmd = &cobra.Command{
+	Use:   "eval --event FILE --contract CONTRACT",
+	Short: "Evaluate transition conditions against a CloudEvent",
+	Long: `Evaluate the conditions of every transition that handles a CloudEvent's type and show whether they hold.
+
+Condition names are dotted paths into the event, e.g. data.signature.validated. Booleans and numbers in the event are
+compared with the condition value after conversion: true and false match only the literals "true" and "false", and
+"1.50" matches 1.5.`,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eventFile, _ := cmd.Flags().GetString("event")
+		contractFile, _ := cmd.Flags().GetString("contract")
+		only, _ := cmd.Flags().GetString("state")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+
+		c, err := loadContract(contractFile)
+		if err != nil {
+			return err
+		}
+		events, err := readEventsFile(eventFile, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return fmt.Errorf("%w: %s contains no events", ErrInvalidEvent, eventFile)
+		}
+
+		var evaluations []transitionEvaluation
+		for i, e := range events {
+			doc := e.document()
+			for _, s := range c.State.States {
+				if only != "" && s.Name != only {
+					continue
+				}
+				for _, t := range s.Transitions {
+					if t.On != e.Type {
+						continue
+					}
+					results, holds := condition.EvaluateAll(t.Conditions, doc)
+					ev := transitionEvaluation{EventIndex: i, Event: e.Type, EventID: e.ID, State: s.Name, Transition: transitionName(t), To: t.To, Satisfied: holds}
+					for _, r := range results {
+						ev.Conditions = append(ev.Conditions, conditionEvaluation{
+							Name:   r.Condition.Name,
+							Want:   r.Condition.Value,
+							Actual: r.Actual,
+							Holds:  r.Holds,
+							Reason: r.Reason(),
+						})
+					}
+					evaluations = append(evaluations, ev)
+				}
+			}
+		}
+
+		if output == "json" {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(evaluations); err != nil {
+				return err
+			}
+		} else {
+			printEvaluations(cmd.OutOrStdout(), events, evaluations)
+		}
+		for _, ev := range evaluations {
+			if ev.Satisfied {
+				return nil
+			}
+		}
+		return ErrNoTransitionSatisfied
+	},
+}
+
+// transitionEvaluation is the outcome of checking one transition against an event.
+type transitionEvaluation struct {
+	// EventIndex is the position of the event in the input, as types and IDs may repeat.
+	EventIndex int                   `json:"eventIndex"`
+	Event      string                `json:"event"`
+	EventID    string                `json:"eventId,omitempty"`
+	State      string                `json:"state"`
+	Transition string                `json:"transition"`
+	To         string                `json:"to"`
+	Satisfied  bool                  `json:"satisfied"`
+	Conditions []conditionEvaluation `json:"conditions"`
+}
+
+type conditionEvaluation struct {
+	Name   string      `json:"name"`
+	Want   string      `json:"want"`
+	Actual interface{} `json:"actual,omitempty"`
+	Holds  bool        `json:"holds"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+func printEvaluations(w io.Writer, events []cloudEvent, evaluations []transitionEvaluation) {
+	for i, e := range events {
+		id := ""
+		if e.ID != "" {
+			id = helpStyle(" id=" + e.ID)
+		}
+		fmt.Fprintf(w, "%s %s%s\n", style.Render("Event"), e.Type, id)
+		found := false
+		for _, ev := range evaluations {
+			if ev.EventIndex != i {
+				continue
+			}
+			found = true
+			status := ErrStyle.Render("not satisfied")
+			if ev.Satisfied {
+				status = successStyle.Render("satisfied")
+			}
+			fmt.Fprintf(w, "  %s %s %s  %s\n", ev.State, helpStyle("── "+ev.Transition+" ─▶"), ev.To, status)
+			for _, c := range ev.Conditions {
+				if c.Holds {
+					fmt.Fprintf(w, "    %s %s = %s\n", successStyle.Render("✓"), c.Name, condition.Format(c.Actual))
+				} else {
+					fmt.Fprintf(w, "    %s %s\n", ErrStyle.Render("✗"), c.Reason)
+				}
+			}
+		}
+		if !found {
+			fmt.Fprintf(w, "  %s\n", warnStyle.Render("no transition handles "+e.Type))
+		}
+	}
+}
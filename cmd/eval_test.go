@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintEvaluations(t *testing.T) {
+	// Events without IDs and of the same type are told apart by their position.
+	events := []cloudEvent{{Type: "com.decombine.signature.sign"}, {Type: "com.decombine.signature.sign"}}
+	evaluations := []transitionEvaluation{
+		{EventIndex: 0, Event: events[0].Type, State: "Draft", Transition: "Signing", To: "In Process"},
+		{EventIndex: 1, Event: events[1].Type, State: "Draft", Transition: "Signing", To: "In Process", Satisfied: true},
+	}
+	var b strings.Builder
+	printEvaluations(&b, events, evaluations)
+	blocks := strings.Split(b.String(), "Event ")[1:]
+	if len(blocks) != 2 {
+		t.Fatalf("printEvaluations() printed %d events, want 2:\n%s", len(blocks), b.String())
+	}
+	for i, want := range []string{"not satisfied", "  satisfied"} {
+		if strings.Count(blocks[i], "Signing") != 1 || !strings.Contains(blocks[i], want) {
+			t.Errorf("event %d: want one evaluation that is %s, got:\n%s", i, strings.TrimSpace(want), blocks[i])
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultFetchTimeout  = 30 * time.Second
+	defaultFetchMaxBytes = 5 << 20
+	maxFetchRedirects    = 5
+)
+
+var (
+	ErrHostNotAllowed   = errors.New("host is not allowed by the fetch policy")
+	ErrResponseTooLarge = errors.New("response exceeds the maximum contract size")
+)
+
+// blockedHosts are never fetched unless explicitly allowed because they expose cloud instance
+// metadata and credentials.
+var blockedHosts = []string{
+	"metadata.google.internal",
+	"metadata.goog",
+	"169.254.169.254",
+	"fd00:ec2::254",
+}
+
+// FetchConfig controls how Smart Legal Contracts are fetched from URLs.
+type FetchConfig struct {
+	// AllowedHosts restricts fetching to the listed hosts. Entries may start with "*." to
+	// match any subdomain. An empty list allows every public host that is not denied; hosts
+	// on loopback and private addresses must be listed to be fetched.
+	AllowedHosts []string `yaml:"allowedHosts,omitempty" toml:"allowedHosts,omitempty" json:"allowedHosts,omitempty"`
+	// DeniedHosts are never fetched, even when they also match AllowedHosts.
+	DeniedHosts []string `yaml:"deniedHosts,omitempty" toml:"deniedHosts,omitempty" json:"deniedHosts,omitempty"`
+	// Timeout is the maximum duration of a fetch, e.g. "30s".
+	Timeout string `yaml:"timeout,omitempty" toml:"timeout,omitempty" json:"timeout,omitempty"`
+	// MaxBytes is the largest contract body that will be read.
+	MaxBytes int64 `yaml:"maxBytes,omitempty" toml:"maxBytes,omitempty" json:"maxBytes,omitempty"`
+}
+
+// contractFetcher retrieves Smart Legal Contracts over HTTP(S), enforcing the host policy and
+// caching responses so unchanged contracts are revalidated with ETag and Last-Modified.
+type contractFetcher struct {
+	client   *http.Client
+	policy   FetchConfig
+	maxBytes int64
+	cacheDir string
+}
+
+// fetchedContract is the body of a fetched contract and the format it was served as.
+type fetchedContract struct {
+	Data   []byte
+	Format string
+	Cached bool
+}
+
+// cacheEntry is the metadata stored next to a cached contract body.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// defaultFetcher is shared by every URL validated in a single invocation.
+var defaultFetcher = sync.OnceValues(newContractFetcher)
+
+// newContractFetcher creates a fetcher from the fetch section of the CLI configuration.
+// Defaults are used when no configuration file exists, but a configuration file that cannot
+// be read is an error so a broken fetch policy never fails open.
+func newContractFetcher() (*contractFetcher, error) {
+	cfg, err := Config()
+	if err != nil && !errors.Is(err, ErrNoConfigFile) {
+		return nil, err
+	}
+	f := &contractFetcher{policy: cfg.Fetch, maxBytes: cfg.Fetch.MaxBytes}
+	if f.maxBytes <= 0 {
+		f.maxBytes = defaultFetchMaxBytes
+	}
+	timeout := defaultFetchTimeout
+	if d, err := time.ParseDuration(cfg.Fetch.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		f.cacheDir = filepath.Join(home, ConfigPath, "cache", "contracts")
+	}
+
+	// Check the resolved address as well as the host name so DNS cannot be used to reach a
+	// blocked address.
+	dialer := &net.Dialer{Timeout: timeout, Control: f.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection, so the resolved address could not be checked.
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil && matchesHost(f.policy.AllowedHosts, strings.ToLower(host)) {
+			// Hosts allowed by name may resolve to private addresses.
+			return (&net.Dialer{Timeout: timeout}).DialContext(ctx, network, address)
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	f.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect from https to %s", req.URL.Scheme)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	return f, nil
+}
+
+// isContractURL reports whether source is an http(s) URL with a host.
+func isContractURL(source string) bool {
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkURL applies the host policy to a URL before it is requested.
+func (f *contractFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrSourceNotSupported, u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	if matchesHost(f.policy.DeniedHosts, host) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+	}
+	if len(f.policy.AllowedHosts) > 0 && !matchesHost(f.policy.AllowedHosts, host) {
+		return fmt.Errorf("%w: %s is not in allowedHosts", ErrHostNotAllowed, host)
+	}
+	if matchesHost(blockedHosts, host) && !matchesHost(f.policy.AllowedHosts, host) {
+		return fmt.Errorf("%w: %s is a metadata endpoint", ErrHostNotAllowed, host)
+	}
+	return nil
+}
+
+// control is the Control function of the dialer. It runs after DNS resolution, just before
+// the connection is made.
+func (f *contractFetcher) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return f.checkAddress(host)
+}
+
+// checkAddress rejects resolved addresses in the loopback, private, link-local and unspecified
+// ranges, where local services and cloud metadata live, unless the address is explicitly
+// allowed.
+func (f *contractFetcher) checkAddress(host string) error {
+	ip := net.ParseIP(host)
+	if ip == nil || matchesHost(f.policy.AllowedHosts, ip.String()) {
+		return nil
+	}
+	if matchesHost(f.policy.DeniedHosts, ip.String()) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, ip)
+	}
+	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || matchesHost(blockedHosts, ip.String()) {
+		return fmt.Errorf("%w: %s is a link-local or metadata address", ErrHostNotAllowed, ip)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() {
+		return fmt.Errorf("%w: %s is a loopback or private address", ErrHostNotAllowed, ip)
+	}
+	return nil
+}
+
+// matchesHost reports whether host matches any pattern. Patterns starting with "*." match
+// any subdomain of the remainder.
+func matchesHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(p, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch retrieves the contract at rawURL, using the cache for conditional requests.
+func (f *contractFetcher) Fetch(ctx context.Context, rawURL string) (*fetchedContract, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml, application/toml;q=0.9, text/plain;q=0.5")
+	req.Header.Set("User-Agent", "contract/"+cliVersion)
+
+	entry, cached := f.readCache(rawURL)
+	if cached != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return &fetchedContract{Data: cached, Format: responseFormat(entry.ContentType, rawURL, cached), Cached: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrResponseTooLarge, f.maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrResponseTooLarge, f.maxBytes)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	f.writeCache(rawURL, data, cacheEntry{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  contentType,
+		Fetched:      time.Now().UTC(),
+	})
+	return &fetchedContract{Data: data, Format: responseFormat(contentType, rawURL, data)}, nil
+}
+
+// responseFormat chooses the decoder for a fetched contract from its Content-Type, falling
+// back to the URL extension and the content itself for generic types.
+func responseFormat(contentType, rawURL string, data []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "yaml"):
+		return "yaml"
+	case strings.HasSuffix(mediaType, "toml"):
+		return "toml"
+	}
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = u.Path
+	}
+	return contractFormat(name, data)
+}
+
+func (f *contractFetcher) cachePaths(rawURL string) (string, string) {
+	sum := sha256.Sum256([]byte(rawURL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(f.cacheDir, key+".body"), filepath.Join(f.cacheDir, key+".json")
+}
+
+// readCache returns the cached metadata and body for rawURL, or a nil body when there is
+// no usable cache entry.
+func (f *contractFetcher) readCache(rawURL string) (cacheEntry, []byte) {
+	var entry cacheEntry
+	if f.cacheDir == "" {
+		return entry, nil
+	}
+	bodyPath, metaPath := f.cachePaths(rawURL)
+	meta, err := os.ReadFile(metaPath)
+	if err != nil || json.Unmarshal(meta, &entry) != nil || entry.URL != rawURL {
+		return entry, nil
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return entry, nil
+	}
+	return entry, body
+}
+
+// writeCache stores a response that can be revalidated later. Caching is best effort and
+// failures are ignored.
+func (f *contractFetcher) writeCache(rawURL string, data []byte, entry cacheEntry) {
+	if f.cacheDir == "" || (entry.ETag == "" && entry.LastModified == "") {
+		return
+	}
+	if err := os.MkdirAll(f.cacheDir, 0700); err != nil {
+		return
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	bodyPath, metaPath := f.cachePaths(rawURL)
+	if os.WriteFile(bodyPath, data, 0600) != nil {
+		return
+	}
+	_ = os.WriteFile(metaPath, meta, 0600)
+}
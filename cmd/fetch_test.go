@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const fetchedBody = `{"name": "c"}`
+
+// testFetcher returns a fetcher with the default policy and its own cache directory.
+func testFetcher(t *testing.T) *contractFetcher {
+	t.Helper()
+	f, err := newContractFetcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.cacheDir = t.TempDir()
+	return f
+}
+
+func TestFetchControl(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed []string
+		denied  []string
+		wantErr bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1::]:443"},
+		{address: "127.0.0.1:80", wantErr: true},
+		{address: "[::1]:80", wantErr: true},
+		{address: "10.0.0.1:443", wantErr: true},
+		{address: "172.16.0.1:443", wantErr: true},
+		{address: "192.168.1.1:443", wantErr: true},
+		{address: "[fd00::1]:443", wantErr: true},
+		{address: "169.254.169.254:80", wantErr: true},
+		{address: "[fe80::1]:80", wantErr: true},
+		{address: "0.0.0.0:80", wantErr: true},
+		{address: "10.0.0.1:443", allowed: []string{"10.0.0.1"}},
+		{address: "169.254.169.254:80", allowed: []string{"169.254.169.254"}},
+		{address: "93.184.216.34:443", denied: []string{"93.184.216.34"}, wantErr: true},
+		{address: "no-port", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			f := testFetcher(t)
+			f.policy = FetchConfig{AllowedHosts: tt.allowed, DeniedHosts: tt.denied}
+			err := f.control("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("control(%s) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed []string
+		denied  []string
+		wantErr bool
+	}{
+		{url: "https://example.com/c.json"},
+		{url: "ftp://example.com/c.json", wantErr: true},
+		{url: "https://a.example.com/c.json", allowed: []string{"*.example.com"}},
+		{url: "https://example.com/c.json", allowed: []string{"*.example.com"}, wantErr: true},
+		{url: "https://other.org/c.json", allowed: []string{"example.com"}, wantErr: true},
+		{url: "https://a.example.com/c.json", allowed: []string{"*.example.com"}, denied: []string{"a.example.com"}, wantErr: true},
+		{url: "https://EXAMPLE.com/c.json", denied: []string{"example.com"}, wantErr: true},
+		{url: "http://metadata.google.internal/", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{url: "http://metadata.google.internal/", allowed: []string{"metadata.google.internal"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			f := testFetcher(t)
+			f.policy = FetchConfig{AllowedHosts: tt.allowed, DeniedHosts: tt.denied}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = f.checkURL(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkURL(%s) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchBlocksLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fetchedBody))
+	}))
+	defer srv.Close()
+
+	f := testFetcher(t)
+	if _, err := f.Fetch(context.Background(), srv.URL); !errors.Is(err, ErrHostNotAllowed) {
+		t.Fatalf("Fetch() of a loopback server error = %v, want %v", err, ErrHostNotAllowed)
+	}
+	f.policy.AllowedHosts = []string{"127.0.0.1"}
+	got, err := f.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() of an allowed loopback server error = %v", err)
+	}
+	if string(got.Data) != fetchedBody || got.Format != "json" {
+		t.Errorf("Fetch() = %q as %s, want %q as json", got.Data, got.Format, fetchedBody)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fetchedBody))
+	}))
+	defer plain.Close()
+	var loop *httptest.Server
+	loop = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, loop.URL+r.URL.Path+"x", http.StatusFound)
+	}))
+	defer loop.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL, http.StatusFound)
+	}))
+	defer secure.Close()
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://metadata.google.internal/", http.StatusFound)
+	}))
+	defer denied.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{name: "https to http", url: secure.URL, wantErr: "refusing redirect from https to http"},
+		{name: "too many", url: loop.URL + "/", wantErr: "stopped after 5 redirects"},
+		{name: "to a blocked host", url: denied.URL, wantErr: ErrHostNotAllowed.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFetcher(t)
+			f.policy.AllowedHosts = []string{"127.0.0.1"}
+			f.client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: secure.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+			_, err := f.Fetch(context.Background(), tt.url)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchMaxBytes(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// Flushing before the body is written sends it without a Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/sized", "/chunked"} {
+		t.Run(path, func(t *testing.T) {
+			f := testFetcher(t)
+			f.policy.AllowedHosts = []string{"127.0.0.1"}
+			f.maxBytes = 99
+			if _, err := f.Fetch(context.Background(), srv.URL+path); !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("Fetch() error = %v, want %v", err, ErrResponseTooLarge)
+			}
+			f.maxBytes = 100
+			if _, err := f.Fetch(context.Background(), srv.URL+path); err != nil {
+				t.Fatalf("Fetch() of exactly maxBytes error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchCache(t *testing.T) {
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		condition string
+	}{
+		{name: "etag", header: "ETag", value: `"v1"`, condition: "If-None-Match"},
+		{name: "last modified", header: "Last-Modified", value: lastModified, condition: "If-Modified-Since"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Header.Get(tt.condition) == tt.value {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set(tt.header, tt.value)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(fetchedBody))
+			}))
+			defer srv.Close()
+
+			f := testFetcher(t)
+			f.policy.AllowedHosts = []string{"127.0.0.1"}
+			first, err := f.Fetch(context.Background(), srv.URL+"/contract")
+			if err != nil || first.Cached {
+				t.Fatalf("first Fetch() = %+v, %v, want a fresh response", first, err)
+			}
+			second, err := f.Fetch(context.Background(), srv.URL+"/contract")
+			if err != nil {
+				t.Fatalf("second Fetch() error = %v", err)
+			}
+			if !second.Cached || string(second.Data) != fetchedBody || second.Format != "json" {
+				t.Errorf("second Fetch() = %+v, want the cached body", second)
+			}
+			if requests != 2 {
+				t.Errorf("server saw %d requests, want 2", requests)
+			}
+		})
+	}
+
+	// Responses without validators are not cached.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fetchedBody))
+	}))
+	defer srv.Close()
+	f := testFetcher(t)
+	f.policy.AllowedHosts = []string{"127.0.0.1"}
+	for i := 0; i < 2; i++ {
+		got, err := f.Fetch(context.Background(), srv.URL)
+		if err != nil || got.Cached {
+			t.Fatalf("Fetch() = %+v, %v, want a fresh response", got, err)
+		}
+	}
+}
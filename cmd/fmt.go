@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/decombine/slc"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrNotFormatted = errors.New("contracts are not formatted")
+	ErrTOMLComments = errors.New("TOML comments would be lost")
+	actionType      = reflect.TypeOf(slc.Action{})
+	contractType    = reflect.TypeOf(slc.Contract{})
+)
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().Bool("check", false, "List the files that are not formatted and exit non-zero instead of rewriting them")
+	fmtCmd.Flags().Bool("diff", false, "Print a unified diff of the changes instead of rewriting the files")
+}
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt CONTRACT...",
+	Short: "Format Smart Legal Contract files",
+	Long: `Rewrite Smart Legal Contract files in a canonical layout, so the same contract is serialized the same way by
+every editor. Directories are searched for contract files and - formats stdin to stdout.
+
+Fields are ordered as in the contract schema, with unknown fields after them in their original order and map
+entries such as postBuild substitutions sorted. Indentation is two spaces, Entry and Exit actions with no content
+are removed, and YAML comments are kept with the fields they annotate. A $schema reference or schema modeline stays
+at the top. TOML tables are written with their keys sorted, and TOML files with comments are not formatted because
+the comments cannot be kept.`,
+	Example: `  contract fmt contracts/
+  contract fmt --check --diff contracts/contract.yaml`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+		diff, _ := cmd.Flags().GetBool("diff")
+		out := cmd.OutOrStdout()
+
+		if len(args) == 1 && args[0] == "-" {
+			data, err := readStdin(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			formatted, err := formatContract(data, contractFormat("", data))
+			if err != nil {
+				return err
+			}
+			if diff {
+				fmt.Fprint(out, unifiedDiff("<stdin>", "<stdin>", string(data), string(formatted)))
+			} else if !check {
+				out.Write(formatted)
+			}
+			if check && !bytes.Equal(data, formatted) {
+				return ErrNotFormatted
+			}
+			return nil
+		}
+
+		files, failures := expandInputs(args)
+		failed := len(failures) > 0
+		for _, f := range failures {
+			for _, d := range f.Diagnostics {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", ErrStyle.Render("error"), d.Message)
+			}
+		}
+		unformatted := false
+		for _, file := range files {
+			changed, err := formatFile(out, file, check, diff)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s %s: %v\n", ErrStyle.Render("error"), file, err)
+				failed = true
+				continue
+			}
+			unformatted = unformatted || changed
+		}
+		switch {
+		case failed:
+			return errors.New("some contracts could not be formatted")
+		case check && unformatted:
+			return ErrNotFormatted
+		}
+		return nil
+	},
+}
+
+// formatFile formats a contract file in place, or reports the changes with --check and --diff.
+// It reports whether the file was not formatted.
+func formatFile(out io.Writer, file string, check, diff bool) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	formatted, err := formatContract(data, contractFormat(file, data))
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(data, formatted) {
+		return false, nil
+	}
+	if diff {
+		fmt.Fprint(out, unifiedDiff(file, file, string(data), string(formatted)))
+	}
+	if check || diff {
+		if !diff {
+			fmt.Fprintln(out, file)
+		}
+		return true, nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return true, err
+	}
+	if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+		return true, err
+	}
+	fmt.Fprintln(out, file)
+	return true, nil
+}
+
+// formatContract returns the canonical serialization of a contract document. Each document of
+// a YAML stream is formatted on its own.
+func formatContract(data []byte, format string) ([]byte, error) {
+	// The schema modeline stays on the first line rather than moving with the first field.
+	directive, data := splitSchemaDirective(data, format)
+	out := bytes.NewBuffer(directive)
+	if format == "yaml" {
+		docs := splitYAMLDocuments(data)
+		for i, doc := range docs {
+			if i > 0 {
+				out.WriteString("---\n")
+			}
+			// Documents with nothing but comments are kept as they are.
+			if !hasYAMLContent(doc) {
+				out.WriteString(doc)
+				continue
+			}
+			formatted, err := formatYAMLDocument([]byte(doc))
+			if err != nil && len(docs) > 1 {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			if err != nil {
+				return nil, err
+			}
+			out.Write(formatted)
+		}
+		return out.Bytes(), nil
+	}
+
+	if format == "toml" && tomlHasComments(data) {
+		return nil, ErrTOMLComments
+	}
+	doc, err := decodeDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if err := encodeDocument(out, canonicalDocument(doc, contractType, "$", nil), format); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func formatYAMLDocument(data []byte) ([]byte, error) {
+	comments := yaml.CommentMap{}
+	var doc interface{}
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.UseOrderedMap(), yaml.CommentToMap(comments)); err != nil {
+		return nil, fmt.Errorf("error decoding YAML contract: %w", err)
+	}
+	doc = canonicalDocument(doc, contractType, "$", comments)
+	opts := []yaml.EncodeOption{yaml.Indent(2), yaml.IndentSequence(true), yaml.UseLiteralStyleIfMultiline(true)}
+	if len(comments) > 0 {
+		opts = append(opts, yaml.WithComment(comments))
+	}
+	return yaml.MarshalWithOptions(doc, opts...)
+}
+
+// splitYAMLDocuments splits a YAML stream on its "---" and "..." markers. Text after a marker
+// on the same line starts the next document. Unlike splitYAMLStream, empty documents are kept
+// so that joining the documents with "---" keeps the markers of the stream.
+func splitYAMLDocuments(data []byte) []string {
+	docs := []string{""}
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if !yamlDocumentMarker.MatchString(strings.TrimRight(line, "\r\n")) {
+			docs[len(docs)-1] += line
+			continue
+		}
+		next := ""
+		if rest := strings.TrimSpace(line[3:]); rest != "" {
+			next = rest + "\n"
+		}
+		docs = append(docs, next)
+	}
+	return docs
+}
+
+// canonicalDocument orders the keys of a document tree as the fields of the Go type t that it
+// decodes into, sorts the keys of maps and removes empty actions. Comments of removed fields are
+// dropped. path is the YAML path of v, as used by yaml.CommentMap.
+func canonicalDocument(v interface{}, t reflect.Type, path string, comments yaml.CommentMap) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		out := make(yaml.MapSlice, 0, len(v))
+		rank := make(map[string]int, len(v))
+		for i, item := range v {
+			key := fmt.Sprint(item.Key)
+			var ft reflect.Type
+			rank[key] = len(v) + i
+			switch {
+			case t != nil && t.Kind() == reflect.Struct:
+				for j := 0; j < t.NumField(); j++ {
+					if f := t.Field(j); f.IsExported() && fieldKey(f, "json") == key {
+						ft, rank[key] = f.Type, j
+						break
+					}
+				}
+			case t != nil && t.Kind() == reflect.Map:
+				ft, rank[key] = t.Elem(), 0
+			}
+			if key == "$schema" && path == "$" {
+				// The schema reference of a JSON contract comes first.
+				rank[key] = -1
+			}
+			child := path + "." + key
+			value := canonicalDocument(item.Value, ft, child, comments)
+			if ft == actionType && isEmptyValue(value) {
+				for p := range comments {
+					if p == child || strings.HasPrefix(p, child+".") {
+						delete(comments, p)
+					}
+				}
+				continue
+			}
+			out = append(out, yaml.MapItem{Key: item.Key, Value: value})
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			ri, rj := rank[fmt.Sprint(out[i].Key)], rank[fmt.Sprint(out[j].Key)]
+			if ri != rj {
+				return ri < rj
+			}
+			// Map entries have the same rank and are sorted by key.
+			return t != nil && t.Kind() == reflect.Map && fmt.Sprint(out[i].Key) < fmt.Sprint(out[j].Key)
+		})
+		return out
+	case []interface{}:
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = canonicalDocument(e, et, fmt.Sprintf("%s[%d]", path, i), comments)
+		}
+		return out
+	}
+	return v
+}
+
+// isEmptyValue reports whether a document value holds nothing but nulls, empty strings and
+// empty collections.
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case yaml.MapSlice:
+		for _, item := range v {
+			if !isEmptyValue(item.Value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// tomlHasComments reports whether a TOML document contains a comment outside of strings.
+func tomlHasComments(data []byte) bool {
+	var quote string
+	s := string(data)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != "":
+			if s[i] == '\\' && quote[0] == '"' {
+				i++
+			} else if strings.HasPrefix(s[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			}
+		case strings.HasPrefix(s[i:], `"""`), strings.HasPrefix(s[i:], `'''`):
+			quote = s[i : i+3]
+			i += 2
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i : i+1]
+		case s[i] == '#':
+			return true
+		}
+	}
+	return false
+}
+
+// unifiedDiff renders the changes from a to b as a unified diff with three lines of context.
+func unifiedDiff(nameA, nameB, a, b string) string {
+	if a == b {
+		return ""
+	}
+	x, y := splitLines(a), splitLines(b)
+	// Longest common subsequence table.
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	type edit struct {
+		op   byte
+		i, j int
+	}
+	var edits []edit
+	for i, j := 0, 0; i < len(x) || j < len(y); {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			edits = append(edits, edit{' ', i, j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk until a run of more than twice the context is unchanged.
+		first := max(0, start-context)
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k
+			} else if k-end > 2*context {
+				break
+			}
+		}
+		last := min(len(edits), end+context+1)
+		var removed, added int
+		for _, e := range edits[first:last] {
+			if e.op != '+' {
+				removed++
+			}
+			if e.op != '-' {
+				added++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(edits[first].i, removed), hunkRange(edits[first].j, added))
+		for _, e := range edits[first:last] {
+			switch e.op {
+			case '+':
+				out.WriteString("+" + y[e.j] + "\n")
+			case '-':
+				out.WriteString("-" + x[e.i] + "\n")
+			default:
+				out.WriteString(" " + x[e.i] + "\n")
+			}
+		}
+		start = last
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// hunkRange formats the start and length of a hunk, counting lines from 1.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatContract(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		in     string
+		want   string
+	}{
+		{
+			name:   "orders keys as the schema",
+			format: "yaml",
+			in:     "state:\n  initial: A\nname: c\nversion: v1\n",
+			want:   "version: v1\nname: c\nstate:\n  initial: A\n",
+		},
+		{
+			name:   "keeps unknown keys after known ones",
+			format: "yaml",
+			in:     "zeta: 1\nname: c\nalpha: 2\n",
+			want:   "name: c\nzeta: 1\nalpha: 2\n",
+		},
+		{
+			name:   "removes empty actions",
+			format: "yaml",
+			in:     "state:\n  states:\n  - name: A\n    entry:\n      actionType: \"\"\n    exit: {}\n",
+			want:   "state:\n  states:\n    - name: A\n",
+		},
+		{
+			name:   "keeps actions with content",
+			format: "yaml",
+			in:     "state:\n  states:\n  - name: A\n    entry:\n      actionType: KubernetesAction\n",
+			want:   "state:\n  states:\n    - name: A\n      entry:\n        actionType: KubernetesAction\n",
+		},
+		{
+			name:   "keeps comments with their fields",
+			format: "yaml",
+			in:     "name: c # the name\nversion: v1\n",
+			want:   "version: v1\nname: c # the name\n",
+		},
+		{
+			name:   "keeps the schema modeline first",
+			format: "yaml",
+			in:     "# yaml-language-server: $schema=./contract.schema.json\nname: c\nversion: v1\n",
+			want:   "# yaml-language-server: $schema=./contract.schema.json\nversion: v1\nname: c\n",
+		},
+		{
+			name:   "formats every document of a stream",
+			format: "yaml",
+			in:     "name: a\nversion: v1\n---\n# nothing here\n---\nname: b\nversion: v2\n",
+			want:   "version: v1\nname: a\n---\n# nothing here\n---\nversion: v2\nname: b\n",
+		},
+		{
+			name:   "keeps a leading document marker",
+			format: "yaml",
+			in:     "---\nname: a\nversion: v1\n",
+			want:   "---\nversion: v1\nname: a\n",
+		},
+		{
+			name:   "puts $schema first in JSON",
+			format: "json",
+			in:     `{"name": "c", "$schema": "./s.json", "version": "v1"}`,
+			want:   "{\n  \"$schema\": \"./s.json\",\n  \"version\": \"v1\",\n  \"name\": \"c\"\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatContract([]byte(tt.in), tt.format)
+			if err != nil {
+				t.Fatalf("formatContract() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("formatContract() =\n%s\nwant\n%s", got, tt.want)
+			}
+			again, err := formatContract(got, tt.format)
+			if err != nil {
+				t.Fatalf("formatContract() of formatted output error = %v", err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("formatContract() is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatContractErrors(t *testing.T) {
+	if _, err := formatContract([]byte("# comment\nname = \"c\"\n"), "toml"); !errors.Is(err, ErrTOMLComments) {
+		t.Errorf("formatContract() of commented TOML error = %v, want %v", err, ErrTOMLComments)
+	}
+	if _, err := formatContract([]byte("name: a\n---\nname: [\n"), "yaml"); err == nil {
+		t.Error("formatContract() of a stream with an invalid document succeeded")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := unifiedDiff("a", "b", "x\n", "x\n"); got != "" {
+		t.Errorf("unifiedDiff() of equal input = %q, want empty", got)
+	}
+	got := unifiedDiff("a", "b", "1\n2\n3\n", "1\nx\n3\n")
+	want := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n"
+	if got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/decombine/slc"
+)
+
+// Dimensions of the state graph layout, in SVG user units.
+const (
+	graphMargin     = 40.0
+	graphNodeHeight = 40.0
+	graphGapX       = 90.0
+	graphGapY       = 36.0
+	graphCharWidth  = 8.0
+)
+
+// stateGraph is a layered layout of a contract's state machine. States are placed in columns
+// by their distance from the initial state; unreachable states form the last column.
+type stateGraph struct {
+	Nodes  []graphNode
+	Edges  []graphEdge
+	Width  float64
+	Height float64
+}
+
+type graphNode struct {
+	Name     string
+	State    int
+	Initial  bool
+	Terminal bool
+	X, Y, W  float64
+}
+
+type graphEdge struct {
+	From, To   int
+	Label      string
+	State      int
+	Transition int
+}
+
+// graphStyle highlights parts of a rendered graph. Nil sets leave the graph unhighlighted.
+type graphStyle struct {
+	States      map[int]bool
+	Transitions map[[2]int]bool
+}
+
+// layoutStateGraph places the states and transitions of a state machine. Transitions to
+// undefined states are left out.
+func layoutStateGraph(sc slc.StateConfiguration) *stateGraph {
+	g := &stateGraph{}
+	index := make(map[string]int)
+	for i, s := range sc.States {
+		if _, ok := index[s.Name]; ok {
+			continue
+		}
+		index[s.Name] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, graphNode{
+			Name:     s.Name,
+			State:    i,
+			Initial:  s.Name == sc.Initial,
+			Terminal: len(s.Transitions) == 0,
+			W:        math.Max(100, float64(len(s.Name))*graphCharWidth+32),
+		})
+	}
+	for i, s := range sc.States {
+		from, ok := index[s.Name]
+		if !ok || g.Nodes[from].State != i {
+			continue
+		}
+		for j, t := range s.Transitions {
+			to, ok := index[t.To]
+			if !ok {
+				continue
+			}
+			g.Edges = append(g.Edges, graphEdge{From: from, To: to, Label: transitionName(t), State: i, Transition: j})
+		}
+	}
+
+	// Breadth-first layers from the initial state.
+	layer := make([]int, len(g.Nodes))
+	for i := range layer {
+		layer[i] = -1
+	}
+	var queue []int
+	if start, ok := index[sc.Initial]; ok {
+		layer[start] = 0
+		queue = append(queue, start)
+	}
+	maxLayer := 0
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Edges {
+			if e.From == n && layer[e.To] < 0 {
+				layer[e.To] = layer[n] + 1
+				maxLayer = max(maxLayer, layer[e.To])
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	for i := range layer {
+		if layer[i] < 0 {
+			layer[i] = maxLayer + 1
+		}
+	}
+
+	columns := make(map[int][]int)
+	for i, l := range layer {
+		columns[l] = append(columns[l], i)
+	}
+	x := graphMargin
+	for l := 0; l <= maxLayer+1; l++ {
+		col := columns[l]
+		if len(col) == 0 {
+			continue
+		}
+		width := 0.0
+		for _, n := range col {
+			width = math.Max(width, g.Nodes[n].W)
+		}
+		for row, n := range col {
+			g.Nodes[n].X = x + (width-g.Nodes[n].W)/2
+			g.Nodes[n].Y = graphMargin + float64(row)*(graphNodeHeight+graphGapY)
+			g.Height = math.Max(g.Height, g.Nodes[n].Y+graphNodeHeight+graphMargin)
+		}
+		x += width + graphGapX
+	}
+	g.Width = x - graphGapX + graphMargin
+	// Leave room for edges routed below the last row.
+	g.Height += graphGapY
+	return g
+}
+
+// svg renders the graph as a standalone SVG document.
+func (g *stateGraph) svg(gs graphStyle) string {
+	highlight := gs.States != nil || gs.Transitions != nil
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="sans-serif" font-size="13">`+"\n",
+		g.Width, g.Height, g.Width, g.Height)
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="context-stroke"/></marker></defs>` + "\n")
+
+	for _, e := range g.Edges {
+		color, dash := "#227BF0", ""
+		if highlight && !gs.Transitions[[2]int{e.State, e.Transition}] {
+			color, dash = "#9a9a9a", ` stroke-dasharray="5,4"`
+		}
+		path, lx, ly := g.edgePath(e)
+		fmt.Fprintf(&b, `<path d="%s" fill="none" stroke="%s" stroke-width="1.5"%s marker-end="url(#arrow)"/>`+"\n", path, color, dash)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="%s">%s</text>`+"\n", lx, ly, color, html.EscapeString(e.Label))
+	}
+
+	for _, n := range g.Nodes {
+		fill, stroke := "#ffffff", "#227BF0"
+		if highlight {
+			if gs.States[n.State] {
+				fill, stroke = "#dff5e1", "#2e9e44"
+			} else {
+				fill, stroke = "#fdecea", "#d93025"
+			}
+		}
+		width := 1.5
+		if n.Initial {
+			width = 3
+		}
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.0f" rx="12" fill="%s" stroke="%s" stroke-width="%.1f"/>`+"\n",
+			n.X, n.Y, n.W, graphNodeHeight, fill, stroke, width)
+		if n.Terminal {
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.0f" rx="9" fill="none" stroke="%s"/>`+"\n",
+				n.X+4, n.Y+4, n.W-8, graphNodeHeight-8, stroke)
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="middle">%s</text>`+"\n",
+			n.X+n.W/2, n.Y+graphNodeHeight/2, html.EscapeString(n.Name))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// edgePath returns the SVG path of an edge and the position of its label. Forward edges are
+// straight; self-loops, backward edges and edges within a column are curved so they do not
+// overlap.
+func (g *stateGraph) edgePath(e graphEdge) (string, float64, float64) {
+	from, to := g.Nodes[e.From], g.Nodes[e.To]
+	if e.From == e.To {
+		x, y := from.X+from.W/2, from.Y
+		return fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", x-12, y, x-30, y-34, x+30, y-34, x+12, y), x, y - 30
+	}
+	if to.X > from.X+from.W {
+		x1, y1 := from.X+from.W, from.Y+graphNodeHeight/2
+		x2, y2 := to.X, to.Y+graphNodeHeight/2
+		return fmt.Sprintf("M%.1f,%.1f L%.1f,%.1f", x1, y1, x2, y2), (x1 + x2) / 2, (y1+y2)/2 - 6
+	}
+	// Route below the nodes with a quadratic curve.
+	x1, y1 := from.X+from.W/2, from.Y+graphNodeHeight
+	x2, y2 := to.X+to.W/2, to.Y+graphNodeHeight
+	cx, cy := (x1+x2)/2, math.Max(y1, y2)+graphGapY
+	return fmt.Sprintf("M%.1f,%.1f Q%.1f,%.1f %.1f,%.1f", x1, y1, cx, cy, x2, y2), cx, cy - 4
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func graphStates() slc.StateConfiguration {
+	return slc.StateConfiguration{Initial: "Draft", States: []slc.State{
+		{Name: "Draft", Transitions: []slc.Transition{
+			{Name: "Submit", To: "Review <1>", On: "submit"},
+			{Name: "Edit", To: "Draft", On: "edit"},
+			{Name: "Lost", To: "Undefined", On: "lose"},
+		}},
+		{Name: "Review <1>", Transitions: []slc.Transition{
+			{Name: "Approve & sign", To: "Signed", On: "approve"},
+			{Name: "Reject", To: "Draft", On: "reject"},
+			{Name: "Cancel", To: "Cancelled", On: "cancel"},
+		}},
+		{Name: "Signed"},
+		{Name: "Cancelled"},
+		{Name: "Orphan", Transitions: []slc.Transition{{Name: "Adopt", To: "Draft", On: "adopt"}}},
+		{Name: "Draft"},
+	}}
+}
+
+func TestLayoutStateGraph(t *testing.T) {
+	g := layoutStateGraph(graphStates())
+
+	if len(g.Nodes) != 5 {
+		t.Fatalf("layoutStateGraph() = %d nodes, want 5 without the duplicate", len(g.Nodes))
+	}
+	if len(g.Edges) != 6 {
+		t.Errorf("layoutStateGraph() = %d edges, want 6 without the undefined target", len(g.Edges))
+	}
+	column := make(map[string]float64)
+	for _, n := range g.Nodes {
+		column[n.Name] = n.X + n.W/2
+		if n.X < graphMargin || n.X+n.W > g.Width-graphMargin || n.Y+graphNodeHeight > g.Height-graphMargin {
+			t.Errorf("node %s at (%v, %v) lies outside the %vx%v graph", n.Name, n.X, n.Y, g.Width, g.Height)
+		}
+	}
+	// Columns follow the distance from the initial state, with unreachable states last.
+	order := []string{"Draft", "Review <1>", "Signed", "Orphan"}
+	for i := 1; i < len(order); i++ {
+		if column[order[i]] <= column[order[i-1]] {
+			t.Errorf("%s is not in a column right of %s", order[i], order[i-1])
+		}
+	}
+	if column["Signed"] != column["Cancelled"] {
+		t.Errorf("Signed and Cancelled are in different columns")
+	}
+	for _, n := range g.Nodes {
+		if n.Initial != (n.Name == "Draft") || n.Terminal != (n.Name == "Signed" || n.Name == "Cancelled") {
+			t.Errorf("node %s initial = %v, terminal = %v", n.Name, n.Initial, n.Terminal)
+		}
+	}
+}
+
+func TestStateGraphSVG(t *testing.T) {
+	g := layoutStateGraph(graphStates())
+	tests := []struct {
+		name  string
+		style graphStyle
+		want  []string
+	}{
+		{name: "plain", want: []string{">Review &lt;1&gt;</text>", ">Approve &amp; sign</text>"}},
+		{
+			name:  "highlighted",
+			style: graphStyle{States: map[int]bool{0: true}, Transitions: map[[2]int]bool{{0, 0}: true}},
+			want:  []string{`fill="#dff5e1"`, `fill="#fdecea"`, `stroke-dasharray="5,4"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg := g.svg(tt.style)
+			dec := xml.NewDecoder(strings.NewReader(svg))
+			var texts []string
+			for {
+				tok, err := dec.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("svg() is not well-formed XML: %v\n%s", err, svg)
+				}
+				if cd, ok := tok.(xml.CharData); ok && strings.TrimSpace(string(cd)) != "" {
+					texts = append(texts, string(cd))
+				}
+			}
+			// Every node and edge has one label.
+			if len(texts) != len(g.Nodes)+len(g.Edges) {
+				t.Errorf("svg() has %d labels, want %d", len(texts), len(g.Nodes)+len(g.Edges))
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(svg, w) {
+					t.Errorf("svg() does not contain %s", w)
+				}
+			}
+		})
+	}
+}
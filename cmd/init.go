@@ -197,6 +197,14 @@ func initProject(n string, c *slc.Contract) error {
 		return fmt.Errorf("error creating README: %v", err)
 	}
 
+	if err = makeExampleTest(rootDirName + "contracts/tests/"); err != nil {
+		return fmt.Errorf("error creating example test: %v", err)
+	}
+
+	if err = makeExampleWorkload(rootDirName + "contracts/workloads/draft/"); err != nil {
+		return fmt.Errorf("error creating example workload: %v", err)
+	}
+
 	enumeratorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("63")).MarginRight(1)
 	rootStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#227BF0"))
 	itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("35"))
@@ -209,7 +217,17 @@ func initProject(n string, c *slc.Contract) error {
 				Child("contract.json").
 				Child("contract.toml").
 				Child("contract.yaml").
-				Child("README.md"),
+				Child("README.md").
+				Child(
+					tree.New().Root("/tests").
+						RootStyle(rootStyle).
+						Child("draft.yaml"),
+				).
+				Child(
+					tree.New().Root("/workloads/draft").
+						RootStyle(rootStyle).
+						Child("kustomization.yaml"),
+				),
 		).
 		Child("/policies").
 		Child(
@@ -279,7 +297,7 @@ func makeProjectDirs(n string) error {
 		return fmt.Errorf("error creating project directory: %v", err)
 	}
 
-	for _, d := range []string{"contracts", "policies", "text"} {
+	for _, d := range []string{"contracts", "contracts/tests", "contracts/workloads/draft", "policies", "text"} {
 		err = os.MkdirAll(n+"/"+d, 0700)
 		if err != nil {
 			return fmt.Errorf("error creating project directory: %v", err)
@@ -304,13 +322,63 @@ func makeREADMEFile(p string) error {
 		return fmt.Errorf("error creating README.md file: %v", err)
 	}
 	defer f.Close()
-	_, err = f.WriteString(local("InitREADME"))
+	_, err = f.WriteString(localize(nil, "InitREADME", nil))
 	if err != nil {
 		return fmt.Errorf("error writing to README.md: %v", err)
 	}
 	return nil
 }
 
+// exampleTest exercises the Draft state of the contract created by makeContract.
+const exampleTest = `# Contract tests. Run them from the project root with: contract test
+contract: ../contract.yaml
+tests:
+  - name: an unvalidated signature keeps the contract in Draft
+    events:
+      - type: com.decombine.signature.sign
+        data:
+          signature:
+            validated: false
+    expect:
+      state: Draft
+      transitions: []
+      actions:
+        - entry: Draft
+`
+
+func makeExampleTest(p string) error {
+	f, err := os.Create(p + "draft.yaml")
+	if err != nil {
+		return fmt.Errorf("error creating draft.yaml file: %v", err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(exampleTest)
+	if err != nil {
+		return fmt.Errorf("error writing to draft.yaml: %v", err)
+	}
+	return nil
+}
+
+// exampleWorkload is the Kustomization applied on entry to the Draft state of the contract
+// created by makeContract.
+const exampleWorkload = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources: []
+`
+
+func makeExampleWorkload(p string) error {
+	f, err := os.Create(p + "kustomization.yaml")
+	if err != nil {
+		return fmt.Errorf("error creating kustomization.yaml file: %v", err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(exampleWorkload)
+	if err != nil {
+		return fmt.Errorf("error writing to kustomization.yaml: %v", err)
+	}
+	return nil
+}
+
 func makeProjectREADMEFiles(p string) error {
 	err := makeREADMEFile(p)
 	if err != nil {
@@ -321,7 +389,7 @@ func makeProjectREADMEFiles(p string) error {
 		return fmt.Errorf("error creating README.md file: %v", err)
 	}
 	defer cf.Close()
-	_, err = cf.WriteString(local("InitContractREADME"))
+	_, err = cf.WriteString(localize(nil, "InitContractREADME", nil))
 	if err != nil {
 		return fmt.Errorf("error writing to README.md: %v", err)
 	}
@@ -1 +1,56 @@
 package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakeContract(t *testing.T) {
+	c := makeContract(&initContract{Name: "My Contract", SourceURL: "https://github.com/myorg/myrepo"})
+	for _, d := range append(validateContract(c), checkKubernetesActions(c, "")...) {
+		t.Errorf("makeContract() %s[%s] %s: %s", d.Severity, d.Rule, d.Field, d.Message)
+	}
+}
+
+func TestInitProject(t *testing.T) {
+	t.Chdir(t.TempDir())
+	c := makeContract(&initContract{Name: "My Contract", SourceURL: "https://github.com/myorg/myrepo"})
+	if err := initProject("My Contract", c); err != nil {
+		t.Fatalf("initProject() error = %v", err)
+	}
+
+	// The workload of the Draft entry action resolves against the project root.
+	for _, name := range []string{"contract.json", "contract.toml", "contract.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join("my-contract", "contracts", name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range checkContract(path, data, contractFormat(path, data)) {
+				if strings.HasPrefix(d.Rule, "kustomization/") || strings.HasPrefix(d.Rule, "schema/") {
+					t.Errorf("%s: %s[%s] %s", path, d.Severity, d.Rule, d.Message)
+				}
+			}
+		})
+	}
+
+	files, err := findTestFiles([]string{"my-contract"})
+	if err != nil {
+		t.Fatalf("findTestFiles() error = %v", err)
+	}
+	if want := filepath.Join("my-contract", "contracts", "tests", "draft.yaml"); len(files) != 1 || files[0] != want {
+		t.Fatalf("findTestFiles() = %q, want [%s]", files, want)
+	}
+	suite := runTestFile(files[0], "")
+	if suite.Err != nil {
+		t.Fatalf("runTestFile() error = %v", suite.Err)
+	}
+	for _, r := range suite.Results {
+		if !r.passed() {
+			t.Errorf("example test %q failed: %v %q", r.Name, r.Err, r.Failures)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/decombine/slc"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// kustomizationFileNames are the file names kustomize accepts for a kustomization.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// deletionPolicies are the values Flux accepts for KustomizationSpec.DeletionPolicy.
+var deletionPolicies = []string{
+	kustomizev1.DeletionPolicyMirrorPrune,
+	kustomizev1.DeletionPolicyDelete,
+	kustomizev1.DeletionPolicyOrphan,
+}
+
+// checkKubernetesActions validates the KubernetesActions in every state's Entry and Exit
+// actions without contacting a cluster. When projectRoot is not empty, each
+// KustomizationSpec.Path must resolve to a kustomization inside the project.
+func checkKubernetesActions(c *slc.Contract, projectRoot string) []diagnostic {
+	var diags []diagnostic
+	for i, s := range c.State.States {
+		for _, a := range []struct {
+			name   string
+			action slc.Action
+		}{{"Entry", s.Entry}, {"Exit", s.Exit}} {
+			for j, ka := range a.action.KubernetesActions {
+				field := fmt.Sprintf("State.States[%d].%s.KubernetesActions[%d]", i, a.name, j)
+				diags = append(diags, checkKubernetesAction(ka, field, projectRoot)...)
+			}
+		}
+	}
+	return diags
+}
+
+func checkKubernetesAction(ka slc.KubernetesAction, field, projectRoot string) []diagnostic {
+	var diags []diagnostic
+	add := func(sev severity, rule, f, format string, args ...interface{}) {
+		diags = append(diags, diagnostic{
+			Severity: sev,
+			Rule:     rule,
+			Field:    field + f,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if errs := validation.IsDNS1123Label(ka.Namespace); ka.Namespace != "" && len(errs) > 0 {
+		add(severityError, "kustomization/namespace", ".Namespace", "namespace %q is not a valid RFC 1123 label: %s", ka.Namespace, strings.Join(errs, "; "))
+	}
+
+	spec := ka.KustomizationSpec
+	if spec == nil {
+		add(severityError, "kustomization/missing-spec", "", "Kubernetes action has no kustomizationSpec")
+		return diags
+	}
+	field += ".KustomizationSpec"
+
+	if spec.Path != "" || projectRoot != "" {
+		diags = append(diags, checkKustomizationPath(spec.Path, field, projectRoot)...)
+	}
+
+	// Prefix and suffix are applied to every resource name, so they must keep the shortest
+	// possible name a valid DNS subdomain.
+	if spec.NamePrefix != "" || spec.NameSuffix != "" {
+		name := spec.NamePrefix + "a" + spec.NameSuffix
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			f := ".NamePrefix"
+			if spec.NamePrefix == "" {
+				f = ".NameSuffix"
+			}
+			add(severityError, "kustomization/name", f, "namePrefix %q and nameSuffix %q produce invalid object names: %s", spec.NamePrefix, spec.NameSuffix, strings.Join(errs, "; "))
+		}
+	}
+	if spec.TargetNamespace != "" {
+		if errs := validation.IsDNS1123Label(spec.TargetNamespace); len(errs) > 0 {
+			add(severityError, "kustomization/namespace", ".TargetNamespace", "targetNamespace %q is not a valid RFC 1123 label: %s", spec.TargetNamespace, strings.Join(errs, "; "))
+		}
+	}
+
+	// An absent interval decodes as zero and is left to the defaults of the runtime.
+	if spec.Interval.Duration < 0 {
+		add(severityWarning, "kustomization/interval", ".Interval", "interval %s is negative", spec.Interval.Duration)
+	}
+	if spec.Timeout != nil && spec.Interval.Duration > 0 && spec.Timeout.Duration > spec.Interval.Duration {
+		add(severityWarning, "kustomization/conflict", ".Timeout", "timeout %s is longer than the reconciliation interval %s", spec.Timeout.Duration, spec.Interval.Duration)
+	}
+	if spec.Wait && len(spec.HealthChecks) > 0 {
+		add(severityWarning, "kustomization/conflict", ".HealthChecks", "healthChecks are ignored when wait is enabled")
+	}
+	if spec.Suspend {
+		add(severityWarning, "kustomization/conflict", ".Suspend", "suspend prevents the workload from ever being applied")
+	}
+	if spec.DeletionPolicy != "" && !contains(deletionPolicies, spec.DeletionPolicy) {
+		add(severityError, "kustomization/conflict", ".DeletionPolicy", "deletionPolicy %q must be one of: %s", spec.DeletionPolicy, strings.Join(deletionPolicies, ", "))
+	}
+	if !spec.Prune && (spec.DeletionPolicy == kustomizev1.DeletionPolicyMirrorPrune) {
+		add(severityWarning, "kustomization/conflict", ".DeletionPolicy", "deletionPolicy %s with prune disabled orphans the workload when the contract leaves this state", spec.DeletionPolicy)
+	}
+	if spec.Decryption != nil && spec.Decryption.Provider != "sops" {
+		add(severityError, "kustomization/conflict", ".Decryption.Provider", "decryption provider %q is not supported, use \"sops\"", spec.Decryption.Provider)
+	}
+	return diags
+}
+
+// checkKustomizationPath requires path to be a directory inside projectRoot that contains
+// a kustomization file.
+func checkKustomizationPath(p, field, projectRoot string) []diagnostic {
+	diag := func(format string, args ...interface{}) []diagnostic {
+		return []diagnostic{{
+			Severity: severityError,
+			Rule:     "kustomization/path",
+			Field:    field + ".Path",
+			Message:  fmt.Sprintf(format, args...),
+		}}
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(p, "./")))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return diag("path %q must be relative to the project and stay inside it", p)
+	}
+	if projectRoot == "" {
+		return nil
+	}
+
+	dir := filepath.Join(projectRoot, clean)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return diag("path %q does not exist in the project at %s", p, projectRoot)
+	}
+	if !info.IsDir() {
+		return diag("path %q is not a directory", p)
+	}
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return nil
+		}
+	}
+	return diag("path %q has no kustomization.yaml", p)
+}
+
+// projectRoot finds the root of the project that contains the contract at path: the directory
+// above "contracts" in the layout created by init project, or else the nearest Git work tree.
+// Contract projects in a monorepo therefore resolve paths against their own root rather than
+// the repository's.
+func projectRoot(path string) string {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return ""
+	}
+	if filepath.Base(dir) == "contracts" {
+		return filepath.Dir(dir)
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	return dir
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/decombine/slc"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckKubernetesActions(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"workloads/app", "workloads/empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "workloads/app/kustomization.yaml"), []byte("resources: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "workloads/file"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	duration := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+	tests := []struct {
+		name      string
+		namespace string
+		spec      *kustomizev1.KustomizationSpec
+		fields    []string
+		rules     []string
+	}{
+		{name: "valid", namespace: "default", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Prune: true, Interval: metav1.Duration{Duration: time.Minute}}},
+		{name: "valid with ./", spec: &kustomizev1.KustomizationSpec{Path: "./workloads/app"}},
+		{name: "no spec", spec: nil, rules: []string{"kustomization/missing-spec"}, fields: []string{""}},
+		{name: "namespace", namespace: "Not_Valid", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app"}, rules: []string{"kustomization/namespace"}, fields: []string{".Namespace"}},
+		{name: "target namespace", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", TargetNamespace: "a.b"}, rules: []string{"kustomization/namespace"}, fields: []string{".KustomizationSpec.TargetNamespace"}},
+		{name: "absolute path", spec: &kustomizev1.KustomizationSpec{Path: "/workloads/app"}, rules: []string{"kustomization/path"}, fields: []string{".KustomizationSpec.Path"}},
+		{name: "path outside the project", spec: &kustomizev1.KustomizationSpec{Path: "workloads/../../app"}, rules: []string{"kustomization/path"}, fields: []string{".KustomizationSpec.Path"}},
+		{name: "missing path", spec: &kustomizev1.KustomizationSpec{Path: "workloads/missing"}, rules: []string{"kustomization/path"}, fields: []string{".KustomizationSpec.Path"}},
+		{name: "path is a file", spec: &kustomizev1.KustomizationSpec{Path: "workloads/file"}, rules: []string{"kustomization/path"}, fields: []string{".KustomizationSpec.Path"}},
+		{name: "path without kustomization", spec: &kustomizev1.KustomizationSpec{Path: "workloads/empty"}, rules: []string{"kustomization/path"}, fields: []string{".KustomizationSpec.Path"}},
+		{name: "prefix", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", NamePrefix: "Draft_"}, rules: []string{"kustomization/name"}, fields: []string{".KustomizationSpec.NamePrefix"}},
+		{name: "suffix", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", NameSuffix: "-"}, rules: []string{"kustomization/name"}, fields: []string{".KustomizationSpec.NameSuffix"}},
+		{name: "negative interval", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Interval: metav1.Duration{Duration: -time.Minute}}, rules: []string{"kustomization/interval"}, fields: []string{".KustomizationSpec.Interval"}},
+		{
+			name:   "timeout longer than interval",
+			spec:   &kustomizev1.KustomizationSpec{Path: "workloads/app", Interval: metav1.Duration{Duration: time.Minute}, Timeout: duration(2 * time.Minute)},
+			rules:  []string{"kustomization/conflict"},
+			fields: []string{".KustomizationSpec.Timeout"},
+		},
+		{name: "timeout without interval", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Timeout: duration(2 * time.Minute)}},
+		{
+			name:   "wait with health checks",
+			spec:   &kustomizev1.KustomizationSpec{Path: "workloads/app", Wait: true, HealthChecks: []meta.NamespacedObjectKindReference{{Kind: "Deployment", Name: "app"}}},
+			rules:  []string{"kustomization/conflict"},
+			fields: []string{".KustomizationSpec.HealthChecks"},
+		},
+		{name: "suspend", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Suspend: true}, rules: []string{"kustomization/conflict"}, fields: []string{".KustomizationSpec.Suspend"}},
+		{name: "deletion policy", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Prune: true, DeletionPolicy: "Keep"}, rules: []string{"kustomization/conflict"}, fields: []string{".KustomizationSpec.DeletionPolicy"}},
+		{name: "mirror prune without prune", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", DeletionPolicy: kustomizev1.DeletionPolicyMirrorPrune}, rules: []string{"kustomization/conflict"}, fields: []string{".KustomizationSpec.DeletionPolicy"}},
+		{name: "mirror prune with prune", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Prune: true, DeletionPolicy: kustomizev1.DeletionPolicyMirrorPrune}},
+		{name: "decryption", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Decryption: &kustomizev1.Decryption{Provider: "vault"}}, rules: []string{"kustomization/conflict"}, fields: []string{".KustomizationSpec.Decryption.Provider"}},
+		{name: "sops decryption", spec: &kustomizev1.KustomizationSpec{Path: "workloads/app", Decryption: &kustomizev1.Decryption{Provider: "sops"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &slc.Contract{State: slc.StateConfiguration{States: []slc.State{{
+				Name: "A",
+				Exit: slc.Action{KubernetesActions: []slc.KubernetesAction{{Namespace: tt.namespace, KustomizationSpec: tt.spec}}},
+			}}}}
+			var rules, fields []string
+			for _, d := range checkKubernetesActions(c, root) {
+				rules = append(rules, d.Rule)
+				fields = append(fields, d.Field[len("State.States[0].Exit.KubernetesActions[0]"):])
+			}
+			if !reflect.DeepEqual(rules, tt.rules) || !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("checkKubernetesActions() = %q at %q, want %q at %q", rules, fields, tt.rules, tt.fields)
+			}
+		})
+	}
+}
+
+func TestCheckKustomizationPathWithoutRoot(t *testing.T) {
+	if diags := checkKustomizationPath("workloads/missing", "f", ""); len(diags) != 0 {
+		t.Errorf("checkKustomizationPath() without a project root = %+v, want none", diags)
+	}
+	if diags := checkKustomizationPath("../outside", "f", ""); len(diags) != 1 {
+		t.Errorf("checkKustomizationPath() of a path outside the project = %+v, want one", diags)
+	}
+}
+
+func TestProjectRoot(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{".git", "services/billing/contracts", "services/billing/other"} {
+		if err := os.MkdirAll(filepath.Join(dir, "repo", d), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "loose"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "contracts directory in a monorepo", path: "repo/services/billing/contracts/c.yaml", want: "repo/services/billing"},
+		{name: "nearest Git work tree", path: "repo/services/billing/other/c.yaml", want: "repo"},
+		{name: "outside a work tree", path: "loose/c.yaml", want: "loose"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := projectRoot(filepath.Join(dir, tt.path)), filepath.Join(dir, tt.want); got != want {
+				t.Errorf("projectRoot() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/decombine/slc"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+// lintConfigFile is the project-local lint configuration, read from the project root.
+const lintConfigFile = ".contract-lint.yaml"
+
+var (
+	ErrLintFailed   = errors.New("one or more contracts have lint errors")
+	ErrUnknownRule  = errors.New("unknown lint rule")
+	ErrUnknownLevel = errors.New("unknown lint level")
+)
+
+// ignoreComment matches "# contract:ignore RULE[, RULE...]". Without rules every finding on
+// the line is ignored.
+var ignoreComment = regexp.MustCompile(`#\s*contract:ignore\b([^#\n]*)`)
+
+// LintConfig enables, disables and re-levels lint rules.
+type LintConfig struct {
+	// Rules maps rule IDs to "error", "warning", "info" or "off".
+	Rules map[string]string `yaml:"rules,omitempty" toml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// lintRule is a house-style check. Rules only report the field and message of a finding;
+// the rule ID and configured severity are filled in by the engine.
+type lintRule struct {
+	ID          string
+	Severity    severity
+	Description string
+	Check       func(t *lintTarget) []diagnostic
+}
+
+// lintTarget is a decoded contract and the project it belongs to.
+type lintTarget struct {
+	Name     string
+	Contract *slc.Contract
+	// Root is the project directory, or empty for contracts fetched from a URL.
+	Root string
+}
+
+// lintRules is the registry of lint rules in the order they run.
+var lintRules []lintRule
+
+// registerLintRule adds a rule to the registry.
+func registerLintRule(r lintRule) {
+	for _, existing := range lintRules {
+		if existing.ID == r.ID {
+			panic("lint rule registered twice: " + r.ID)
+		}
+	}
+	lintRules = append(lintRules, r)
+	ruleDescriptions[r.ID] = r.Description
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringArray("rule", []string{}, "Set the level of a rule, e.g. --rule style/state-name=off. Levels: error, warning, info, off")
+	lintCmd.Flags().Bool("list-rules", false, "List the available lint rules and exit")
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [contracts...]",
+	Short: "Check Smart Legal Contracts against house-style rules",
+	Long: `Lint Smart Legal Contracts with softer, house-style rules than validate.
+
+Rules are configured in the lint section of the CLI configuration and in a .contract-lint.yaml file at the root of
+the project, which takes precedence:
+
+  rules:
+    style/empty-exit: off
+    style/state-name: error
+
+Findings in YAML and TOML contracts can be suppressed with a comment on the same or the preceding line:
+
+  - name: draft  # contract:ignore style/state-name
+
+Inputs are handled like validate: files, directories, globs, URLs or contracts piped to stdin.`,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list-rules"); list {
+			printLintRules(cmd.OutOrStdout())
+			return nil
+		}
+
+		// Without a config file the rules keep their default severities.
+		cfg, err := Config()
+		if err != nil && !errors.Is(err, ErrNoConfigFile) {
+			return err
+		}
+		overrides, _ := cmd.Flags().GetStringArray("rule")
+		flagRules, err := parseRuleFlags(overrides)
+		if err != nil {
+			return err
+		}
+		if err := checkLintConfig(cfg.Lint); err != nil {
+			return err
+		}
+		l := &linter{global: cfg.Lint, flags: flagRules, projects: make(map[string]LintConfig)}
+
+		var results []contractResult
+		inputs := args
+		if len(inputs) == 0 {
+			data, err := readStdin(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if isPathList(data) {
+				inputs = strings.Fields(string(data))
+			} else {
+				results = append(results, l.lintStream(stdinName, data)...)
+			}
+		}
+
+		targets, failures := expandInputs(inputs)
+		results = append(results, failures...)
+		for _, t := range targets {
+			data, format, err := readContract(t)
+			if err != nil {
+				results = append(results, failedResult(t, "source/read", err))
+				continue
+			}
+			results = append(results, l.lint(t, data, format))
+		}
+		if l.err != nil {
+			return l.err
+		}
+
+		out := cmd.OutOrStdout()
+		s := summarize(results)
+		var infos int
+		for _, r := range results {
+			if len(r.Diagnostics) == 0 {
+				continue
+			}
+			fmt.Fprintln(out, style.Render(r.Name))
+			printDiagnostics(out, r.data, r.Diagnostics)
+			for _, d := range r.Diagnostics {
+				if d.Severity == severityInfo {
+					infos++
+				}
+			}
+		}
+		fmt.Fprintf(out, "\n%s %d contract(s): %d error(s), %d warning(s), %d info\n",
+			style.Render("Linted"), s.Total, s.Errors, s.Warnings, infos)
+		if s.Invalid > 0 {
+			return ErrLintFailed
+		}
+		return nil
+	},
+}
+
+// linter applies the registered rules with the levels from the CLI configuration, the
+// project file and the command line, in increasing precedence.
+type linter struct {
+	global   LintConfig
+	flags    LintConfig
+	projects map[string]LintConfig
+	err      error
+}
+
+// lint runs every enabled rule against a single contract document.
+func (l *linter) lint(name string, data []byte, format string) contractResult {
+	c, err := decodeContract(data, format)
+	if err != nil {
+		diag := decodeDiagnostic(name, data, err)
+		return contractResult{Name: name, Format: format, Diagnostics: []diagnostic{diag}, data: data}
+	}
+	t := &lintTarget{Name: name, Contract: c}
+	if !isContractURL(name) {
+		t.Root = projectRoot(name)
+	}
+	levels := l.levels(t.Root)
+
+	var diags []diagnostic
+	for _, rule := range lintRules {
+		level, ok := levels[rule.ID]
+		if !ok {
+			level = string(rule.Severity)
+		}
+		if level == "off" {
+			continue
+		}
+		for _, d := range rule.Check(t) {
+			d.Rule = rule.ID
+			d.Severity = severity(level)
+			diags = append(diags, d)
+		}
+	}
+	locateDiagnostics(name, data, format, diags)
+	if format != "json" {
+		diags = suppressIgnored(data, diags)
+	}
+	return contractResult{Name: name, Format: format, Valid: !hasErrors(diags), Diagnostics: diags, data: data}
+}
+
+// lintStream lints each contract document in piped content.
+func (l *linter) lintStream(name string, data []byte) []contractResult {
+	var results []contractResult
+	for _, doc := range splitContractStream(data) {
+		r := l.lint(name, doc.Data, doc.Format)
+		doc.position(r.Diagnostics)
+		r.Name = fmt.Sprintf("%s[%d]", name, doc.Index)
+		r.data = data
+		results = append(results, r)
+	}
+	return results
+}
+
+// levels merges the configured rule levels for a project.
+func (l *linter) levels(root string) map[string]string {
+	levels := make(map[string]string)
+	for id, level := range l.global.Rules {
+		levels[id] = strings.ToLower(level)
+	}
+	project, ok := l.projects[root]
+	if !ok && root != "" {
+		var err error
+		project, err = readLintConfig(filepath.Join(root, lintConfigFile))
+		if err != nil && l.err == nil {
+			l.err = err
+		}
+		l.projects[root] = project
+	}
+	for id, level := range project.Rules {
+		levels[id] = strings.ToLower(level)
+	}
+	for id, level := range l.flags.Rules {
+		levels[id] = level
+	}
+	return levels
+}
+
+// readLintConfig reads a project lint file. A missing file is an empty configuration.
+func readLintConfig(path string) (LintConfig, error) {
+	var cfg LintConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err := checkLintConfig(cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// checkLintConfig rejects unknown rule IDs and levels so typos do not silently disable a rule.
+func checkLintConfig(cfg LintConfig) error {
+	for id, level := range cfg.Rules {
+		if !isLintRule(id) {
+			return fmt.Errorf("%w: %s", ErrUnknownRule, id)
+		}
+		switch strings.ToLower(level) {
+		case "off", string(severityError), string(severityWarning), string(severityInfo):
+		default:
+			return fmt.Errorf("%w %q for rule %s", ErrUnknownLevel, level, id)
+		}
+	}
+	return nil
+}
+
+// parseRuleFlags parses --rule ID=LEVEL flags.
+func parseRuleFlags(flags []string) (LintConfig, error) {
+	cfg := LintConfig{Rules: make(map[string]string)}
+	for _, f := range flags {
+		id, level, ok := strings.Cut(f, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid --rule %q, expected RULE=LEVEL", f)
+		}
+		cfg.Rules[strings.TrimSpace(id)] = strings.ToLower(strings.TrimSpace(level))
+	}
+	return cfg, checkLintConfig(cfg)
+}
+
+func isLintRule(id string) bool {
+	for _, r := range lintRules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// suppressIgnored drops diagnostics on lines carrying a "contract:ignore" comment, or
+// directly below a line that contains only such a comment.
+func suppressIgnored(data []byte, diags []diagnostic) []diagnostic {
+	lines := strings.Split(string(data), "\n")
+	ignored := func(line int, rule string) bool {
+		if line < 1 || line > len(lines) {
+			return false
+		}
+		m := ignoreComment.FindStringSubmatch(lines[line-1])
+		if m == nil {
+			return false
+		}
+		rules := strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' })
+		if len(rules) == 0 {
+			return true
+		}
+		for _, r := range rules {
+			if r == rule {
+				return true
+			}
+		}
+		return false
+	}
+
+	var kept []diagnostic
+	for _, d := range diags {
+		line := d.Span.Line
+		if ignored(line, d.Rule) {
+			continue
+		}
+		if line > 1 && line <= len(lines) && strings.HasPrefix(strings.TrimSpace(lines[line-2]), "#") && ignored(line-1, d.Rule) {
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// printLintRules writes the registry as a table.
+func printLintRules(w io.Writer) {
+	rules := append([]lintRule(nil), lintRules...)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tLEVEL\tDESCRIPTION")
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.ID, r.Severity, r.Description)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLinterLevels(t *testing.T) {
+	root := t.TempDir()
+	project := "rules:\n  style/state-name: info\n  style/event-type: error\n"
+	if err := os.WriteFile(filepath.Join(root, lintConfigFile), []byte(project), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l := &linter{
+		global:   LintConfig{Rules: map[string]string{"style/state-name": "ERROR", "style/empty-exit": "off"}},
+		flags:    LintConfig{Rules: map[string]string{"style/event-type": "off"}},
+		projects: make(map[string]LintConfig),
+	}
+
+	tests := []struct {
+		name string
+		root string
+		want map[string]string
+	}{
+		{
+			name: "project overrides global and flags override project",
+			root: root,
+			want: map[string]string{"style/state-name": "info", "style/empty-exit": "off", "style/event-type": "off"},
+		},
+		{
+			name: "no project",
+			root: "",
+			want: map[string]string{"style/state-name": "error", "style/empty-exit": "off", "style/event-type": "off"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.levels(tt.root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("levels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if l.err != nil {
+		t.Fatalf("levels() error = %v", l.err)
+	}
+
+	bad := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bad, lintConfigFile), []byte("rules:\n  style/no-such-rule: off\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l.levels(bad)
+	if !errors.Is(l.err, ErrUnknownRule) {
+		t.Errorf("levels() of an unknown rule error = %v, want %v", l.err, ErrUnknownRule)
+	}
+}
+
+func TestLint(t *testing.T) {
+	const contract = `version: v1.0.0
+name: c
+state:
+  initial: draft
+  states:
+    - name: draft
+      transitions:
+        - name: Sign
+          to: Signed
+          on: sign
+    - name: Signed
+`
+	tests := []struct {
+		name  string
+		flags map[string]string
+		want  []string
+	}{
+		{name: "default levels", want: []string{"warning style/event-type", "info style/empty-exit", "warning style/state-name"}},
+		{
+			name:  "re-levelled and disabled",
+			flags: map[string]string{"style/state-name": "error", "style/empty-exit": "off"},
+			want:  []string{"warning style/event-type", "error style/state-name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &linter{flags: LintConfig{Rules: tt.flags}, projects: make(map[string]LintConfig)}
+			r := l.lint(filepath.Join(t.TempDir(), "c.yaml"), []byte(contract), "yaml")
+			var got []string
+			for _, d := range r.Diagnostics {
+				got = append(got, string(d.Severity)+" "+d.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lint() = %q, want %q", got, tt.want)
+			}
+			if r.Valid != (len(tt.flags) == 0) {
+				t.Errorf("lint() valid = %v", r.Valid)
+			}
+		})
+	}
+}
+
+func TestSuppressIgnored(t *testing.T) {
+	const data = `state:
+  states:
+    - name: draft  # contract:ignore
+    - name: review  # contract:ignore style/event-type, style/state-name
+    # contract:ignore style/state-name
+    - name: signed
+    - name: closed  # contract:ignore style/event-type
+    - name: archived
+`
+	diags := []diagnostic{
+		{Rule: "style/state-name", Span: sourceSpan{Line: 3}},
+		{Rule: "style/state-name", Span: sourceSpan{Line: 4}},
+		{Rule: "style/state-name", Span: sourceSpan{Line: 6}},
+		{Rule: "style/empty-exit", Span: sourceSpan{Line: 6}},
+		{Rule: "style/state-name", Span: sourceSpan{Line: 7}},
+		// A trailing comment does not cover the next line.
+		{Rule: "style/state-name", Span: sourceSpan{Line: 8}},
+		{Rule: "style/state-name"},
+	}
+	var got []sourceSpan
+	for _, d := range suppressIgnored([]byte(data), diags) {
+		got = append(got, d.Span)
+	}
+	want := []sourceSpan{{Line: 6}, {Line: 7}, {Line: 8}, {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("suppressIgnored() kept %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// cloudEventType matches reverse-DNS CloudEvent types such as com.decombine.signature.sign.
+// The final segment may be camelCase, e.g. com.decombine.contract.expirationReached.
+var cloudEventType = regexp.MustCompile(`^[a-z][a-z0-9-]*(\.[a-z0-9-]+)+\.[a-zA-Z][a-zA-Z0-9]*$`)
+
+// titleCaseName matches state names such as "Draft" and "In Process".
+var titleCaseName = regexp.MustCompile(`^[A-Z0-9][A-Za-z0-9]*( [A-Z0-9][A-Za-z0-9]*)*$`)
+
+func init() {
+	registerLintRule(lintRule{
+		ID:          "style/event-type",
+		Severity:    severityWarning,
+		Description: "Transition events should be reverse-DNS CloudEvent types, e.g. com.example.contract.signed.",
+		Check:       lintEventTypes,
+	})
+	registerLintRule(lintRule{
+		ID:          "style/empty-exit",
+		Severity:    severityInfo,
+		Description: "States that can be left should clean up after themselves with an Exit action.",
+		Check:       lintEmptyExits,
+	})
+	registerLintRule(lintRule{
+		ID:          "style/state-name",
+		Severity:    severityWarning,
+		Description: "State names should be in Title Case, e.g. \"In Process\".",
+		Check:       lintStateNames,
+	})
+	registerLintRule(lintRule{
+		ID:          "style/text-url",
+		Severity:    severityWarning,
+		Description: "The contract text should live in the contract's own project.",
+		Check:       lintTextURL,
+	})
+}
+
+func lintEventTypes(t *lintTarget) []diagnostic {
+	var diags []diagnostic
+	for i, s := range t.Contract.State.States {
+		for j, tr := range s.Transitions {
+			if tr.On == "" || cloudEventType.MatchString(tr.On) {
+				continue
+			}
+			diags = append(diags, diagnostic{
+				Field:   fmt.Sprintf("State.States[%d].Transitions[%d].On", i, j),
+				Message: fmt.Sprintf("event %q of transition %q is not a reverse-DNS CloudEvent type such as com.example.contract.signed", tr.On, tr.Name),
+			})
+		}
+	}
+	return diags
+}
+
+func lintEmptyExits(t *lintTarget) []diagnostic {
+	var diags []diagnostic
+	for i, s := range t.Contract.State.States {
+		// Terminal states are never left, so they have nothing to clean up.
+		if len(s.Transitions) == 0 || len(s.Exit.KubernetesActions) > 0 {
+			continue
+		}
+		diags = append(diags, diagnostic{
+			Field:   fmt.Sprintf("State.States[%d].Exit", i),
+			Message: fmt.Sprintf("state %q has no exit actions", s.Name),
+		})
+	}
+	return diags
+}
+
+func lintStateNames(t *lintTarget) []diagnostic {
+	var diags []diagnostic
+	for i, s := range t.Contract.State.States {
+		if s.Name == "" || titleCaseName.MatchString(s.Name) {
+			continue
+		}
+		diags = append(diags, diagnostic{
+			Field:   fmt.Sprintf("State.States[%d].Name", i),
+			Message: fmt.Sprintf("state name %q should be in Title Case, e.g. %q", s.Name, titleCase(s.Name)),
+		})
+	}
+	return diags
+}
+
+// titleCase suggests a Title Case spelling of a state name.
+func titleCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '_' || r == '-'
+	})
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+func lintTextURL(t *lintTarget) []diagnostic {
+	c := t.Contract
+	if c.Text.URL == "" {
+		return nil
+	}
+	diag := func(format string, args ...interface{}) []diagnostic {
+		return []diagnostic{{Field: "Text.URL", Message: fmt.Sprintf(format, args...)}}
+	}
+
+	u, err := url.Parse(c.Text.URL)
+	if err != nil || u.Scheme == "" {
+		// A relative text URL is a path within the project.
+		if t.Root == "" {
+			return nil
+		}
+		p := filepath.Clean(filepath.FromSlash(c.Text.URL))
+		if filepath.IsAbs(p) || strings.HasPrefix(p, "..") {
+			return diag("text %q is outside the project", c.Text.URL)
+		}
+		if _, err := os.Stat(filepath.Join(t.Root, p)); err != nil {
+			return diag("text %q does not exist in the project", c.Text.URL)
+		}
+		return nil
+	}
+
+	repo, err := url.Parse(c.Source.URL)
+	if c.Source.URL == "" || err != nil || repo.Host == "" {
+		return nil
+	}
+	if !inRepository(u, repo) {
+		return diag("text %s is not in the contract repository %s", c.Text.URL, c.Source.URL)
+	}
+	return nil
+}
+
+// inRepository reports whether u points at a file in the repository at repo. Raw GitHub URLs
+// are treated as part of the github.com repository they serve.
+func inRepository(u, repo *url.URL) bool {
+	repoPath := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	host := strings.ToLower(u.Hostname())
+	if host == "raw.githubusercontent.com" && strings.EqualFold(repo.Hostname(), "github.com") {
+		host = "github.com"
+	}
+	if host != strings.ToLower(repo.Hostname()) {
+		return false
+	}
+	p := strings.Trim(u.Path, "/")
+	return repoPath == "" || p == repoPath || strings.HasPrefix(p, repoPath+"/")
+}
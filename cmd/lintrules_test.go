@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func TestLintEventTypes(t *testing.T) {
+	tests := []struct {
+		on   string
+		want bool
+	}{
+		{on: "com.decombine.signature.sign"},
+		{on: "com.decombine.contract.expirationReached"},
+		{on: "io.example-corp.v2.paid"},
+		{on: ""},
+		{on: "sign", want: true},
+		{on: "Com.Example.signed", want: true},
+		{on: "com.example.contract.signed_v2", want: true},
+	}
+	for _, tt := range tests {
+		c := &slc.Contract{State: slc.StateConfiguration{States: []slc.State{{Name: "A", Transitions: []slc.Transition{{To: "A", On: tt.on}}}}}}
+		if got := len(lintEventTypes(&lintTarget{Contract: c})) > 0; got != tt.want {
+			t.Errorf("lintEventTypes(%q) reported = %v, want %v", tt.on, got, tt.want)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "draft", want: "Draft"},
+		{name: "in_process", want: "In Process"},
+		{name: "awaiting-signature  now", want: "Awaiting Signature Now"},
+	}
+	for _, tt := range tests {
+		if got := titleCase(tt.name); got != tt.want {
+			t.Errorf("titleCase(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if !titleCaseName.MatchString(tt.want) {
+			t.Errorf("titleCase(%q) = %q, which style/state-name rejects", tt.name, tt.want)
+		}
+	}
+}
+
+func TestInRepository(t *testing.T) {
+	tests := []struct {
+		text string
+		repo string
+		want bool
+	}{
+		{text: "https://github.com/acme/contracts/blob/main/text.md", repo: "https://github.com/acme/contracts.git", want: true},
+		{text: "https://raw.githubusercontent.com/acme/contracts/main/text.md", repo: "https://github.com/acme/contracts", want: true},
+		{text: "https://GitHub.com/acme/contracts/text.md", repo: "https://github.com/acme/contracts", want: true},
+		{text: "https://github.com/acme/contracts-fork/text.md", repo: "https://github.com/acme/contracts"},
+		{text: "https://example.com/acme/contracts/text.md", repo: "https://github.com/acme/contracts"},
+		{text: "https://git.example.com/any/text.md", repo: "https://git.example.com", want: true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.text)
+		repo, _ := url.Parse(tt.repo)
+		if got := inRepository(u, repo); got != tt.want {
+			t.Errorf("inRepository(%s, %s) = %v, want %v", tt.text, tt.repo, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decombine/contract/pkg/condition"
+	"github.com/decombine/slc"
+)
+
+var ErrUndefinedState = errors.New("undefined state")
+
+// machine executes the state machine of a Smart Legal Contract one event at a time, without
+// performing any of its actions.
+type machine struct {
+	contract *slc.Contract
+	states   map[string]int
+	current  string
+	observer machineObserver
+}
+
+// machineObserver is notified as a machine runs, e.g. to measure coverage. States and
+// transitions are identified by their index in the contract.
+type machineObserver interface {
+	entered(state int)
+	evaluated(state, transition int, results []condition.Result)
+	fired(state, transition int)
+}
+
+// step is the outcome of starting the machine or delivering one event to it.
+type step struct {
+	Event      *cloudEvent `json:"event,omitempty"`
+	From       string      `json:"from,omitempty"`
+	To         string      `json:"to"`
+	Transition string      `json:"transition,omitempty"`
+	Fired      bool        `json:"fired"`
+	Exit       []string    `json:"exit,omitempty"`
+	Entry      []string    `json:"entry,omitempty"`
+	// Reason explains why an event did not fire a transition.
+	Reason string `json:"reason,omitempty"`
+}
+
+func newMachine(c *slc.Contract) (*machine, error) {
+	m := &machine{contract: c, states: make(map[string]int)}
+	for i, s := range c.State.States {
+		if _, ok := m.states[s.Name]; !ok {
+			m.states[s.Name] = i
+		}
+	}
+	if _, ok := m.states[c.State.Initial]; !ok {
+		return nil, fmt.Errorf("%w: initial state %q", ErrUndefinedState, c.State.Initial)
+	}
+	return m, nil
+}
+
+func (m *machine) state(name string) *slc.State {
+	return &m.contract.State.States[m.states[name]]
+}
+
+// start enters the initial state.
+func (m *machine) start() step {
+	m.current = m.contract.State.Initial
+	if m.observer != nil {
+		m.observer.entered(m.states[m.current])
+	}
+	return step{To: m.current, Fired: true, Entry: describeAction(m.state(m.current).Entry)}
+}
+
+// enter places the machine in a state without running its Entry action, as if the contract
+// had already reached it.
+func (m *machine) enter(name string) error {
+	if _, ok := m.states[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUndefinedState, name)
+	}
+	m.current = name
+	return nil
+}
+
+// fire delivers an event to the current state. The first transition whose event type matches
+// and whose conditions hold is taken.
+func (m *machine) fire(e cloudEvent) (step, error) {
+	s := step{Event: &e, From: m.current, To: m.current}
+	from := m.states[m.current]
+	doc := e.document()
+	var reasons []string
+	for j, t := range m.state(m.current).Transitions {
+		if t.On != e.Type {
+			continue
+		}
+		results, holds := condition.EvaluateAll(t.Conditions, doc)
+		if m.observer != nil {
+			m.observer.evaluated(from, j, results)
+		}
+		if !holds {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", transitionName(t), failedCondition(results)))
+			continue
+		}
+		to, ok := m.states[t.To]
+		if !ok {
+			return s, fmt.Errorf("%w: transition %s targets %q", ErrUndefinedState, transitionName(t), t.To)
+		}
+		if m.observer != nil {
+			m.observer.fired(from, j)
+			m.observer.entered(to)
+		}
+		s.Transition = transitionName(t)
+		s.Fired = true
+		s.To = t.To
+		s.Exit = describeAction(m.state(m.current).Exit)
+		s.Entry = describeAction(m.state(t.To).Entry)
+		m.current = t.To
+		return s, nil
+	}
+	if len(reasons) == 0 {
+		s.Reason = fmt.Sprintf("no transition from %q handles %s", m.current, e.Type)
+	} else {
+		s.Reason = strings.Join(reasons, "; ")
+	}
+	return s, nil
+}
+
+// terminal reports whether the current state has no way out.
+func (m *machine) terminal() bool {
+	return len(m.state(m.current).Transitions) == 0
+}
+
+// failedCondition explains the first condition that does not hold.
+func failedCondition(results []condition.Result) string {
+	for _, r := range results {
+		if !r.Holds {
+			return r.Reason()
+		}
+	}
+	return ""
+}
+
+func transitionName(t slc.Transition) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.On
+}
+
+// describeAction summarizes what an Entry or Exit action would do on a Network.
+func describeAction(a slc.Action) []string {
+	var out []string
+	for _, ka := range a.KubernetesActions {
+		ns := ka.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		if ka.KustomizationSpec == nil {
+			out = append(out, fmt.Sprintf("Kubernetes action in namespace %s without a Kustomization", ns))
+			continue
+		}
+		out = append(out, fmt.Sprintf("apply Kustomization %s in namespace %s", ka.KustomizationSpec.Path, ns))
+	}
+	if len(out) == 0 && a.ActionType != "" {
+		out = append(out, a.ActionType)
+	}
+	return out
+}
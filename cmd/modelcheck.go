@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decombine/slc"
+)
+
+// stateSpace is the transition system of a contract's state machine over its event alphabet.
+// Conditions are not evaluated: a transition with conditions may or may not fire, so an event
+// that only conditional transitions handle may also leave the contract where it is.
+// States are identified by the index of their first definition in the contract.
+type stateSpace struct {
+	contract *slc.Contract
+	index    map[string]int
+	alphabet []string
+	edges    map[int][]spaceEdge
+	final    map[int]bool
+	bound    int
+}
+
+// spaceEdge is a transition that can fire when the contract receives Event in state From.
+type spaceEdge struct {
+	From       string `json:"from"`
+	Event      string `json:"event"`
+	Transition string `json:"transition"`
+	To         string `json:"to"`
+
+	from, to int
+}
+
+// verifyFinding is a logical flaw found while exploring the state space. Path is a shortest
+// sequence of transitions from the initial state that exhibits it.
+type verifyFinding struct {
+	Severity severity    `json:"severity"`
+	Kind     string      `json:"kind"`
+	States   []string    `json:"states,omitempty"`
+	Message  string      `json:"message"`
+	Path     []spaceEdge `json:"path,omitempty"`
+}
+
+// newStateSpace builds the state space of a contract. Final states are those the contract is
+// meant to end in; when none are given every state without transitions is final. Transitions
+// that can never fire because an earlier transition always handles their event first are left
+// out and reported.
+func newStateSpace(c *slc.Contract, finals []string, bound int) (*stateSpace, []verifyFinding, error) {
+	m, err := newMachine(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	sp := &stateSpace{contract: c, index: m.states, edges: make(map[int][]spaceEdge), final: make(map[int]bool), bound: bound}
+	for _, name := range finals {
+		i, ok := sp.index[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("final state: %w: %q", ErrUndefinedState, name)
+		}
+		sp.final[i] = true
+	}
+
+	var findings []verifyFinding
+	events := make(map[string]bool)
+	for i, s := range c.State.States {
+		if sp.index[s.Name] != i {
+			continue
+		}
+		if len(finals) == 0 && len(s.Transitions) == 0 {
+			sp.final[i] = true
+		}
+		for j, t := range s.Transitions {
+			if t.On == "" {
+				continue
+			}
+			events[t.On] = true
+			if k := shadowingTransition(s.Transitions, j); k >= 0 {
+				findings = append(findings, verifyFinding{
+					Severity: severityWarning,
+					Kind:     "shadowed",
+					States:   []string{s.Name},
+					Message: fmt.Sprintf("transition %s in %q never fires: %s handles %s whenever its conditions hold",
+						transitionName(t), s.Name, transitionName(s.Transitions[k]), t.On),
+				})
+				continue
+			}
+			to, ok := sp.index[t.To]
+			if !ok {
+				// validate reports transitions to undefined states.
+				continue
+			}
+			sp.edges[i] = append(sp.edges[i], spaceEdge{From: s.Name, Event: t.On, Transition: transitionName(t), To: t.To, from: i, to: to})
+		}
+	}
+	for e := range events {
+		sp.alphabet = append(sp.alphabet, e)
+	}
+	sort.Strings(sp.alphabet)
+	return sp, findings, nil
+}
+
+// shadowingTransition returns the index of an earlier transition that handles the same event
+// with a subset of the conditions of transition j, or -1. Because the first matching transition
+// fires, transition j can then never be taken.
+func shadowingTransition(ts []slc.Transition, j int) int {
+	for k := 0; k < j; k++ {
+		if ts[k].On != ts[j].On {
+			continue
+		}
+		subset := true
+		for _, pc := range ts[k].Conditions {
+			found := false
+			for _, c := range ts[j].Conditions {
+				if pc.Name == c.Name && pc.Value == c.Value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				subset = false
+				break
+			}
+		}
+		if subset {
+			return k
+		}
+	}
+	return -1
+}
+
+func (sp *stateSpace) name(i int) string {
+	return sp.contract.State.States[i].Name
+}
+
+// search explores the states reachable from a state within the bound, breadth first. It
+// returns the edge each state was first reached by, and whether the bound cut the search short.
+func (sp *stateSpace) search(from int) (map[int]*spaceEdge, bool) {
+	parents := map[int]*spaceEdge{from: nil}
+	depth := map[int]int{from: 0}
+	queue := []int{from}
+	truncated := false
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for k, e := range sp.edges[n] {
+			if _, ok := parents[e.to]; ok {
+				continue
+			}
+			if depth[n] >= sp.bound {
+				truncated = true
+				continue
+			}
+			parents[e.to] = &sp.edges[n][k]
+			depth[e.to] = depth[n] + 1
+			queue = append(queue, e.to)
+		}
+	}
+	return parents, truncated
+}
+
+// spacePath follows the parents of a search back to its start.
+func spacePath(parents map[int]*spaceEdge, to int) []spaceEdge {
+	var p []spaceEdge
+	for e := parents[to]; e != nil; e = parents[e.from] {
+		p = append([]spaceEdge{*e}, p...)
+	}
+	return p
+}
+
+// canLeave reports whether some transition moves the contract out of a state.
+func (sp *stateSpace) canLeave(i int) bool {
+	for _, e := range sp.edges[i] {
+		if e.to != i {
+			return true
+		}
+	}
+	return false
+}
+
+// check explores the states reachable from the initial state and reports deadlocks, live-locks
+// and states from which no final state can be reached.
+func (sp *stateSpace) check() (explored int, findings []verifyFinding) {
+	initial := sp.index[sp.contract.State.Initial]
+	parents, truncated := sp.search(initial)
+	explored = len(parents)
+	reached := sortedStates(parents)
+	if truncated {
+		findings = append(findings, verifyFinding{
+			Severity: severityInfo,
+			Kind:     "bound",
+			Message:  fmt.Sprintf("exploration stopped after %d events; states beyond the bound were not checked", sp.bound),
+		})
+	}
+	if len(sp.final) == 0 {
+		return explored, append(findings, verifyFinding{
+			Severity: severityError,
+			Kind:     "no-final",
+			Message:  "the contract has no final state",
+		})
+	}
+
+	flagged := make(map[int]bool)
+	for _, i := range reached {
+		if sp.final[i] || sp.canLeave(i) {
+			continue
+		}
+		flagged[i] = true
+		msg := fmt.Sprintf("the contract cannot leave %q", sp.name(i))
+		if len(sp.contract.State.States[i].Transitions) == 0 {
+			msg = fmt.Sprintf("state %q has no transitions but is not a final state", sp.name(i))
+		}
+		findings = append(findings, verifyFinding{
+			Severity: severityError,
+			Kind:     "deadlock",
+			States:   []string{sp.name(i)},
+			Message:  msg,
+			Path:     spacePath(parents, i),
+		})
+	}
+
+	for _, scc := range sp.components(parents) {
+		if len(scc) < 2 || sp.exits(scc) {
+			continue
+		}
+		names := make([]string, len(scc))
+		for k, i := range scc {
+			names[k] = sp.name(i)
+			flagged[i] = true
+		}
+		findings = append(findings, verifyFinding{
+			Severity: severityError,
+			Kind:     "live-lock",
+			States:   names,
+			Message:  fmt.Sprintf("states %s form a cycle with no exit", quoteNames(names)),
+			Path:     spacePath(parents, scc[0]),
+		})
+	}
+
+	toFinal := sp.reachesFinal()
+	for _, i := range reached {
+		if toFinal[i] || flagged[i] {
+			continue
+		}
+		findings = append(findings, verifyFinding{
+			Severity: severityError,
+			Kind:     "no-final",
+			States:   []string{sp.name(i)},
+			Message:  fmt.Sprintf("no final state can be reached from %q", sp.name(i)),
+			Path:     spacePath(parents, i),
+		})
+	}
+	return explored, findings
+}
+
+// components returns the strongly connected components of the explored states, using
+// Tarjan's algorithm.
+func (sp *stateSpace) components(explored map[int]*spaceEdge) [][]int {
+	var (
+		sccs    [][]int
+		stack   []int
+		next    int
+		index   = make(map[int]int)
+		low     = make(map[int]int)
+		onStack = make(map[int]bool)
+	)
+	var visit func(n int)
+	visit = func(n int) {
+		index[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, e := range sp.edges[n] {
+			if _, ok := explored[e.to]; !ok {
+				continue
+			}
+			if _, seen := index[e.to]; !seen {
+				visit(e.to)
+				low[n] = min(low[n], low[e.to])
+			} else if onStack[e.to] {
+				low[n] = min(low[n], index[e.to])
+			}
+		}
+		if low[n] != index[n] {
+			return
+		}
+		var scc []int
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			scc = append(scc, top)
+			if top == n {
+				break
+			}
+		}
+		sort.Ints(scc)
+		sccs = append(sccs, scc)
+	}
+	for _, n := range sortedStates(explored) {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	return sccs
+}
+
+// exits reports whether a set of states contains a final state or a transition out of the set.
+func (sp *stateSpace) exits(states []int) bool {
+	in := make(map[int]bool, len(states))
+	for _, i := range states {
+		in[i] = true
+	}
+	for _, i := range states {
+		if sp.final[i] {
+			return true
+		}
+		for _, e := range sp.edges[i] {
+			if !in[e.to] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reachesFinal returns the states from which a final state can be reached.
+func (sp *stateSpace) reachesFinal() map[int]bool {
+	reverse := make(map[int][]int)
+	for _, edges := range sp.edges {
+		for _, e := range edges {
+			reverse[e.to] = append(reverse[e.to], e.from)
+		}
+	}
+	seen := make(map[int]bool)
+	var queue []int
+	for i := range sp.final {
+		seen[i] = true
+		queue = append(queue, i)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, p := range reverse[n] {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return seen
+}
+
+func sortedStates[V any](m map[int]V) []int {
+	out := make([]int, 0, len(m))
+	for i := range m {
+		out = append(out, i)
+	}
+	sort.Ints(out)
+	return out
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func TestStateSpaceCheck(t *testing.T) {
+	to := func(target, on string) slc.Transition {
+		return slc.Transition{Name: target, To: target, On: on}
+	}
+	tests := []struct {
+		name   string
+		states []slc.State
+		finals []string
+		bound  int
+		kinds  []string
+	}{
+		{
+			name: "valid",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e")}},
+				{Name: "B"},
+			},
+		},
+		{
+			name: "terminal state that is not final",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e"), to("C", "f")}},
+				{Name: "B"},
+				{Name: "C"},
+			},
+			finals: []string{"C"},
+			kinds:  []string{"deadlock"},
+		},
+		{
+			name: "self-loop",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e"), to("C", "f")}},
+				{Name: "B", Transitions: []slc.Transition{to("B", "e")}},
+				{Name: "C"},
+			},
+			kinds: []string{"deadlock"},
+		},
+		{
+			name: "cycle with no exit",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e"), to("D", "f")}},
+				{Name: "B", Transitions: []slc.Transition{to("C", "e")}},
+				{Name: "C", Transitions: []slc.Transition{to("B", "e")}},
+				{Name: "D"},
+			},
+			kinds: []string{"live-lock"},
+		},
+		{
+			name: "no final state",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e")}},
+				{Name: "B", Transitions: []slc.Transition{to("A", "e")}},
+			},
+			kinds: []string{"no-final"},
+		},
+		{
+			name: "shadowed transition",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					to("B", "e"),
+					{Name: "guarded", To: "C", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "true"}}},
+				}},
+				{Name: "B"},
+				{Name: "C"},
+			},
+			kinds: []string{"shadowed"},
+		},
+		{
+			name: "conditions do not shadow",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					{Name: "guarded", To: "C", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "true"}}},
+					to("B", "e"),
+				}},
+				{Name: "B"},
+				{Name: "C"},
+			},
+		},
+		{
+			name: "bound",
+			states: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("B", "e")}},
+				{Name: "B", Transitions: []slc.Transition{to("C", "e")}},
+				{Name: "C"},
+			},
+			bound: 1,
+			kinds: []string{"bound"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &slc.Contract{Name: "Test", State: slc.StateConfiguration{Initial: "A", States: tt.states}}
+			bound := tt.bound
+			if bound == 0 {
+				bound = 100
+			}
+			sp, findings, err := newStateSpace(c, tt.finals, bound)
+			if err != nil {
+				t.Fatalf("newStateSpace() error = %v", err)
+			}
+			_, more := sp.check()
+			var kinds []string
+			for _, f := range append(findings, more...) {
+				kinds = append(kinds, f.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("check() kinds = %q, want %q", kinds, tt.kinds)
+			}
+		})
+	}
+}
+
+func TestStateSpacePath(t *testing.T) {
+	c := &slc.Contract{Name: "Test", State: slc.StateConfiguration{Initial: "A", States: []slc.State{
+		{Name: "A", Transitions: []slc.Transition{{Name: "go", To: "B", On: "e"}, {Name: "end", To: "D", On: "f"}}},
+		{Name: "B", Transitions: []slc.Transition{{Name: "on", To: "C", On: "g"}}},
+		{Name: "C"},
+		{Name: "D"},
+	}}}
+	sp, _, err := newStateSpace(c, []string{"D"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, findings := sp.check()
+	// B only leads to the deadlock in C, so no final state can be reached from it either.
+	if len(findings) != 2 || findings[0].Kind != "deadlock" || findings[1].Kind != "no-final" {
+		t.Fatalf("check() = %+v, want a deadlock and no-final", findings)
+	}
+	var path []string
+	for _, e := range findings[0].Path {
+		path = append(path, e.Transition)
+	}
+	if want := []string{"go", "on"}; !reflect.DeepEqual(path, want) {
+		t.Errorf("deadlock path = %q, want %q", path, want)
+	}
+
+	if _, _, err := newStateSpace(c, []string{"X"}, 100); !errors.Is(err, ErrUndefinedState) {
+		t.Errorf("newStateSpace() with an undefined final state error = %v, want %v", err, ErrUndefinedState)
+	}
+}
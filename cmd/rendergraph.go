@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(renderGraphCmd)
+	renderGraphCmd.Flags().StringP("format", "f", "svg", "The output format. Options: svg, ascii")
+	renderGraphCmd.Flags().String("file", "", "Write the graph to this file instead of stdout")
+}
+
+var renderGraphCmd = &cobra.Command{
+	Use:   "render-graph CONTRACT",
+	Short: "Render the state graph of a Smart Legal Contract",
+	Long: `Render the state machine of a Smart Legal Contract without Graphviz.
+
+The svg format lays out the states in columns by their distance from the initial state and writes a standalone
+SVG document. The ascii format draws the state machine as a tree for the terminal: each state is expanded
+under the first transition that reaches it, and other transitions to it are marked with ↺.`,
+	Example: `  contract render-graph contract.yaml --file docs/states.svg
+  contract render-graph contract.yaml --format ascii`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		if format != "svg" && format != "ascii" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, format)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		var out string
+		if format == "svg" {
+			out = layoutStateGraph(c.State).svg(graphStyle{})
+		} else {
+			out = stateTree(c).String() + "\n"
+		}
+
+		if file == "" || file == "-" {
+			_, err = io.WriteString(cmd.OutOrStdout(), out)
+			return err
+		}
+		if err := os.WriteFile(file, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", successStyle.Render("Wrote"), filepath.Clean(file))
+		return nil
+	},
+}
+
+// stateTree draws a state machine as a tree rooted at the initial state. Each state is
+// expanded under the transition that first reaches it breadth first; states that cannot be
+// reached from the initial state are drawn as further roots.
+func stateTree(c *slc.Contract) *tree.Tree {
+	g := layoutStateGraph(c.State)
+	stateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#227BF0"))
+	enumeratorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("63")).MarginRight(1)
+
+	// expandedBy records the edge under which each state is expanded.
+	expandedBy := make(map[int]int)
+	visited := make(map[int]bool)
+	var roots []int
+	for n, node := range g.Nodes {
+		if node.Initial {
+			roots = append(roots, n)
+		}
+	}
+	expand := func(root int) {
+		visited[root] = true
+		queue := []int{root}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for k, e := range g.Edges {
+				if e.From == n && !visited[e.To] {
+					visited[e.To] = true
+					expandedBy[e.To] = k
+					queue = append(queue, e.To)
+				}
+			}
+		}
+	}
+	for _, r := range roots {
+		expand(r)
+	}
+	for n := range g.Nodes {
+		if !visited[n] {
+			roots = append(roots, n)
+			expand(n)
+		}
+	}
+
+	label := func(n int) string {
+		node := g.Nodes[n]
+		name := stateStyle.Render(node.Name)
+		switch {
+		case node.Initial:
+			name = "● " + name
+		case node.Terminal:
+			name = "◎ " + name
+		}
+		var actions []string
+		s := c.State.States[node.State]
+		for _, a := range describeAction(s.Entry) {
+			actions = append(actions, "entry: "+a)
+		}
+		for _, a := range describeAction(s.Exit) {
+			actions = append(actions, "exit: "+a)
+		}
+		if len(actions) > 0 {
+			name += " " + helpStyle("("+strings.Join(actions, "; ")+")")
+		}
+		return name
+	}
+	var build func(n int) *tree.Tree
+	build = func(n int) *tree.Tree {
+		t := tree.New().Root(label(n))
+		for k, e := range g.Edges {
+			if e.From != n {
+				continue
+			}
+			tr := c.State.States[e.State].Transitions[e.Transition]
+			arrow := textStyle(strings.Join(edgeLabel(tr), " ")) + helpStyle(" ─▶ ")
+			if i, ok := expandedBy[e.To]; ok && i == k {
+				t.Child(build(e.To).Root(arrow + label(e.To)))
+				continue
+			}
+			t.Child(arrow + "↺ " + stateStyle.Render(g.Nodes[e.To].Name))
+		}
+		return t.Enumerator(tree.RoundedEnumerator).EnumeratorStyle(enumeratorStyle)
+	}
+
+	t := tree.Root(style.Render(c.Name)).Enumerator(tree.RoundedEnumerator).EnumeratorStyle(enumeratorStyle)
+	for _, r := range roots {
+		sub := build(r)
+		if !g.Nodes[r].Initial {
+			sub.Root(label(r) + " " + warnStyle.Render("(unreachable)"))
+		}
+		t.Child(sub)
+	}
+	return t
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportWriters maps each supported --report-format to the function that renders it.
+var reportWriters = map[string]func(io.Writer, []contractResult) error{
+	"json":  writeJSONReport,
+	"sarif": writeSARIFReport,
+	"junit": writeJUnitReport,
+}
+
+// writeReport renders the results in the given format to path, or stdout when path is empty.
+func writeReport(stdout io.Writer, results []contractResult, format, path string) error {
+	write, ok := reportWriters[format]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrReportFormatNotSupported, format)
+	}
+	if path == "" || path == "-" {
+		return write(stdout, results)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type jsonReport struct {
+	Tool      string           `json:"tool"`
+	Version   string           `json:"version"`
+	Generated time.Time        `json:"generated"`
+	Summary   reportSummary    `json:"summary"`
+	Contracts []contractResult `json:"contracts"`
+}
+
+type reportSummary struct {
+	Total    int `json:"total"`
+	Valid    int `json:"valid"`
+	Invalid  int `json:"invalid"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+func summarize(results []contractResult) reportSummary {
+	var s reportSummary
+	for _, r := range results {
+		s.Total++
+		if r.Valid {
+			s.Valid++
+		} else {
+			s.Invalid++
+		}
+		for _, d := range r.Diagnostics {
+			switch d.Severity {
+			case severityError:
+				s.Errors++
+			case severityWarning:
+				s.Warnings++
+			}
+		}
+	}
+	return s
+}
+
+func writeJSONReport(w io.Writer, results []contractResult) error {
+	for i := range results {
+		if results[i].Diagnostics == nil {
+			results[i].Diagnostics = []diagnostic{}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonReport{
+		Tool:      "contract",
+		Version:   cliVersion,
+		Generated: time.Now().UTC(),
+		Summary:   summarize(results),
+		Contracts: results,
+	})
+}
+
+// The SARIF types cover the subset of SARIF 2.1.0 needed for code scanning uploads.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+	// OriginalURIBaseIDs resolves the base that relative artifact URIs are written against.
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func writeSARIFReport(w io.Writer, results []contractResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "contract",
+			Version:        cliVersion,
+			InformationURI: "https://decombine.com",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if wd, err := os.Getwd(); err == nil {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{sarifSourceRoot: {URI: fileURI(wd) + "/"}}
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			if !seen[d.Rule] {
+				seen[d.Rule] = true
+				rule := sarifRule{ID: d.Rule}
+				if desc := ruleDescription(d.Rule); desc != "" {
+					rule.ShortDescription = &sarifMessage{Text: desc}
+				}
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+			}
+
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact(d.Span.File)}
+			if d.Span.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: d.Span.Line, StartColumn: d.Span.Column}
+				if d.Span.Length > 0 {
+					loc.Region.EndColumn = d.Span.Column + d.Span.Length
+				}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    d.Rule,
+				Level:     sarifLevel(d.Severity),
+				Message:   sarifMessage{Text: d.Message},
+				Locations: []sarifLocation{{PhysicalLocation: loc}},
+			})
+		}
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifSourceRoot is the URI base of contracts inside the working directory, which code
+// scanning resolves to the root of the checkout.
+const sarifSourceRoot = "%SRCROOT%"
+
+// sarifArtifact locates a contract for SARIF: URLs as they are, files inside the working
+// directory relative to %SRCROOT% and other files as file:// URIs.
+func sarifArtifact(name string) sarifArtifactLocation {
+	if strings.Contains(name, "://") {
+		return sarifArtifactLocation{URI: name}
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return sarifArtifactLocation{URI: (&url.URL{Path: filepath.ToSlash(name)}).String()}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return sarifArtifactLocation{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: sarifSourceRoot}
+		}
+	}
+	return sarifArtifactLocation{URI: fileURI(abs)}
+}
+
+// fileURI converts an absolute path into a file:// URI.
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// Windows paths such as C:/contracts.
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func sarifLevel(s severity) string {
+	switch s {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	}
+	return "note"
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnitReport(w io.Writer, results []contractResult) error {
+	summary := summarize(results)
+	suite := junitTestSuite{
+		Name:      "contract validate",
+		Tests:     summary.Total,
+		Failures:  summary.Invalid,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, r := range results {
+		tc := junitTestCase{Name: r.Name, ClassName: "contract.validate", Time: r.Duration}
+		suite.Time += r.Duration
+
+		var errs, warns []string
+		for _, d := range r.Diagnostics {
+			line := fmt.Sprintf("%s: %s[%s]: %s", d.Span, d.Severity, d.Rule, d.Message)
+			if d.Severity == severityError {
+				errs = append(errs, line)
+			} else {
+				warns = append(warns, line)
+			}
+		}
+		if !r.Valid {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d validation error(s)", len(errs)),
+				Type:    "validation",
+				Text:    strings.Join(errs, "\n"),
+			}
+		}
+		if len(warns) > 0 {
+			tc.SystemOut = strings.Join(warns, "\n")
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{
+		Name:     "contract",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
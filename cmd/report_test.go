@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func reportResults() []contractResult {
+	return []contractResult{
+		{Name: "contracts/a.yaml", Format: "yaml", Valid: true, Duration: 0.5, Diagnostics: []diagnostic{
+			{Severity: severityWarning, Rule: "state/unreachable", Message: "state \"B\" is not reachable", Span: sourceSpan{File: "contracts/a.yaml", Line: 3, Column: 5, Length: 4}},
+		}},
+		{Name: "contracts/b.json", Format: "json", Valid: false, Duration: 0.25, Diagnostics: []diagnostic{
+			{Severity: severityError, Rule: "state/no-exit", Message: "state \"A\" has no way out", Span: sourceSpan{File: "contracts/b.json", Line: 2, Column: 3}},
+			{Severity: severityError, Rule: "schema/required", Message: "name is required", Span: sourceSpan{File: "contracts/b.json"}},
+			{Severity: severityInfo, Rule: "state/no-exit", Message: "again", Span: sourceSpan{File: "contracts/b.json"}},
+		}},
+		{Name: "contracts/c.toml", Format: "toml", Valid: true},
+	}
+}
+
+func TestSARIFArtifact(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	tests := []struct {
+		name string
+		want sarifArtifactLocation
+	}{
+		{name: "contracts/a.yaml", want: sarifArtifactLocation{URI: "contracts/a.yaml", URIBaseID: sarifSourceRoot}},
+		{name: "./my contract.yaml", want: sarifArtifactLocation{URI: "my%20contract.yaml", URIBaseID: sarifSourceRoot}},
+		{name: filepath.Join(dir, "b.json"), want: sarifArtifactLocation{URI: "b.json", URIBaseID: sarifSourceRoot}},
+		{name: "../outside.yaml", want: sarifArtifactLocation{URI: fileURI(filepath.Join(filepath.Dir(dir), "outside.yaml"))}},
+		{name: "https://example.com/c.json", want: sarifArtifactLocation{URI: "https://example.com/c.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sarifArtifact(tt.name); got != tt.want {
+				t.Errorf("sarifArtifact(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/srv/contracts/a.yaml", want: "file:///srv/contracts/a.yaml"},
+		{path: "/srv/my contracts/a#1.yaml", want: "file:///srv/my%20contracts/a%231.yaml"},
+		{path: "C:/contracts/a.yaml", want: "file:///C:/contracts/a.yaml"},
+	}
+	for _, tt := range tests {
+		if got := fileURI(tt.path); got != tt.want {
+			t.Errorf("fileURI(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSARIFReport(t *testing.T) {
+	t.Chdir(t.TempDir())
+	var buf bytes.Buffer
+	if err := writeSARIFReport(&buf, reportResults()); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("writeSARIFReport() wrote invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("writeSARIFReport() = version %s with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	var rules []string
+	for _, r := range run.Tool.Driver.Rules {
+		rules = append(rules, r.ID)
+		if r.ShortDescription == nil || r.ShortDescription.Text != ruleDescription(r.ID) {
+			t.Errorf("rule %s description = %+v, want %q", r.ID, r.ShortDescription, ruleDescription(r.ID))
+		}
+	}
+	if want := []string{"schema/required", "state/no-exit", "state/unreachable"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("writeSARIFReport() rules = %q, want %q", rules, want)
+	}
+
+	var levels []string
+	for _, r := range run.Results {
+		levels = append(levels, r.Level)
+	}
+	if want := []string{"warning", "error", "error", "note"}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("writeSARIFReport() levels = %q, want %q", levels, want)
+	}
+	loc := run.Results[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation != (sarifArtifactLocation{URI: "contracts/a.yaml", URIBaseID: sarifSourceRoot}) {
+		t.Errorf("writeSARIFReport() artifact = %+v", loc.ArtifactLocation)
+	}
+	if loc.Region == nil || *loc.Region != (sarifRegion{StartLine: 3, StartColumn: 5, EndColumn: 9}) {
+		t.Errorf("writeSARIFReport() region = %+v, want line 3 columns 5-9", loc.Region)
+	}
+	if run.Results[2].Locations[0].PhysicalLocation.Region != nil {
+		t.Error("writeSARIFReport() wrote a region for an unknown position")
+	}
+	if base, ok := run.OriginalURIBaseIDs[sarifSourceRoot]; !ok || !strings.HasPrefix(base.URI, "file:///") || !strings.HasSuffix(base.URI, "/") {
+		t.Errorf("writeSARIFReport() %s = %+v, want the working directory as a file URI", sarifSourceRoot, base)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJUnitReport(&buf, reportResults()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Error("writeJUnitReport() has no XML header")
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("writeJUnitReport() wrote invalid XML: %v", err)
+	}
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Time != 0.75 || len(suites.Suites) != 1 {
+		t.Fatalf("writeJUnitReport() = %d tests, %d failures in %vs", suites.Tests, suites.Failures, suites.Time)
+	}
+	cases := suites.Suites[0].Cases
+	if len(cases) != 3 {
+		t.Fatalf("writeJUnitReport() = %d cases, want 3", len(cases))
+	}
+	if cases[0].Failure != nil || cases[0].SystemOut != "contracts/a.yaml:3:5: warning[state/unreachable]: state \"B\" is not reachable" {
+		t.Errorf("valid case with a warning = %+v", cases[0])
+	}
+	f := cases[1].Failure
+	if f == nil || f.Message != "2 validation error(s)" || strings.Count(f.Text, "\n") != 1 || !strings.Contains(f.Text, "error[schema/required]") {
+		t.Errorf("invalid case failure = %+v", f)
+	}
+	if cases[2].Failure != nil || cases[2].SystemOut != "" {
+		t.Errorf("clean case = %+v", cases[2])
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	var stdout bytes.Buffer
+	if err := writeReport(&stdout, reportResults(), "json", path); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 {
+		t.Error("writeReport() to a file wrote to stdout")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	want := reportSummary{Total: 3, Valid: 2, Invalid: 1, Errors: 2, Warnings: 1}
+	if report.Summary != want {
+		t.Errorf("writeReport() summary = %+v, want %+v", report.Summary, want)
+	}
+	// Contracts without diagnostics have an empty list rather than null.
+	if !strings.Contains(string(data), `"diagnostics": []`) {
+		t.Errorf("writeReport() wrote null diagnostics:\n%s", data)
+	}
+
+	if err := writeReport(&stdout, nil, "json", "-"); err != nil || stdout.Len() == 0 {
+		t.Errorf("writeReport() to - = %v, wrote %d bytes to stdout", err, stdout.Len())
+	}
+	if err := writeReport(&stdout, nil, "csv", ""); !errors.Is(err, ErrReportFormatNotSupported) {
+		t.Errorf("writeReport() error = %v, want %v", err, ErrReportFormatNotSupported)
+	}
+	if err := writeReport(&stdout, nil, "json", filepath.Join(path, "x")); err == nil {
+		t.Error("writeReport() into a file path succeeded")
+	}
+}
@@ -71,8 +71,8 @@ type NetworkClient struct {
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "contract",
-	Short: local("RootDescriptionShort"),
-	Long:  rootLogoStyle.Render(local("RootDescriptionLong")),
+	Short: localize(nil, "RootDescriptionShort", nil),
+	Long:  rootLogoStyle.Render(localize(nil, "RootDescriptionLong", nil)),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -135,12 +135,18 @@ func initLang(lang string) *i18n.Localizer {
 	return i18n.NewLocalizer(bundle, lang)
 }
 
-func local(id string) string {
-	l := initLang(Lang)
+// localize translates a message with template data using the given localizer, or the
+// language of the CLI when l is nil.
+func localize(l *i18n.Localizer, id string, data interface{}) string {
+	if l == nil {
+		l = initLang(Lang)
+	}
 	return l.MustLocalize(&i18n.LocalizeConfig{
 		DefaultMessage: &i18n.Message{
 			ID: id,
-		}})
+		},
+		TemplateData: data,
+	})
 }
 
 type ContractCLIConfig struct {
@@ -148,4 +154,6 @@ type ContractCLIConfig struct {
 	DefaultContractFileType string        `yaml:"defaultContractFileType" toml:"defaultContractFileType" json:"defaultContractFileType"`
 	DefaultNetwork          string        `yaml:"defaultNetwork" toml:"defaultNetwork" json:"defaultNetwork"`
 	Networks                []slc.Network `yaml:"networks" toml:"networks" json:"networks"`
+	Fetch                   FetchConfig   `yaml:"fetch,omitempty" toml:"fetch,omitempty" json:"fetch,omitempty"`
+	Lint                    LintConfig    `yaml:"lint,omitempty" toml:"lint,omitempty" json:"lint,omitempty"`
 }
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	// yamlSchemaModeline points the YAML language server at a schema.
+	yamlSchemaModeline = "# yaml-language-server: $schema="
+	// tomlSchemaDirective points Taplo based TOML editors at a schema.
+	tomlSchemaDirective = "#:schema "
+	// durationPattern matches the Go durations accepted by Kubernetes APIs.
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+)
+
+var ErrNoSchemaReference = errors.New("no schema reference: use --file or --ref with --link")
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.Flags().String("file", "", "Write the schema to this file instead of stdout")
+	schemaCmd.Flags().String("id", "", "The $id of the schema, e.g. the URL it is published at")
+	schemaCmd.Flags().StringSlice("link", nil, "Reference the schema from these contract files or directories")
+	schemaCmd.Flags().String("ref", "", "The schema reference written by --link (default the path of --file relative to each contract)")
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Generate a JSON Schema for Smart Legal Contracts",
+	Long: `Generate a JSON Schema (draft 2020-12) for Smart Legal Contracts, including the Flux Kustomization spec of
+Kubernetes actions, so editors can complete and check contracts as they are written.
+
+With --link the schema is referenced from contracts: JSON contracts get a $schema field, YAML contracts a
+yaml-language-server modeline and TOML contracts a #:schema directive. The reference is the path of --file
+relative to each contract, or --ref when the schema is published elsewhere. The schema file itself and other
+JSON Schema documents are never linked.`,
+	Example: `  contract schema --file contract.schema.json --link contracts/
+  contract schema --id https://example.com/contract.schema.json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		id, _ := cmd.Flags().GetString("id")
+		links, _ := cmd.Flags().GetStringSlice("link")
+		ref, _ := cmd.Flags().GetString("ref")
+		if len(links) > 0 && ref == "" && (file == "" || file == "-") {
+			return ErrNoSchemaReference
+		}
+
+		data, err := json.MarshalIndent(contractSchema(id), "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		out := cmd.OutOrStdout()
+		switch {
+		case file != "" && file != "-":
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s %s\n", successStyle.Render("Wrote"), filepath.Clean(file))
+		case file == "-" || len(links) == 0:
+			if _, err := out.Write(data); err != nil {
+				return err
+			}
+			// Keep stdout for the schema alone.
+			out = cmd.ErrOrStderr()
+		}
+		if len(links) == 0 {
+			return nil
+		}
+
+		files, failures := expandInputs(links)
+		failed := len(failures) > 0
+		for _, f := range failures {
+			for _, d := range f.Diagnostics {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", ErrStyle.Render("error"), d.Message)
+			}
+		}
+		for _, contract := range files {
+			if file != "" && file != "-" && sameFile(contract, file) {
+				continue
+			}
+			target := ref
+			if target == "" {
+				if target, err = schemaPath(contract, file); err != nil {
+					return err
+				}
+			}
+			changed, err := linkSchema(contract, target)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s %s: %v\n", ErrStyle.Render("error"), contract, err)
+				failed = true
+				continue
+			}
+			if changed {
+				fmt.Fprintf(out, "%s %s\n", successStyle.Render("Linked"), contract)
+			}
+		}
+		if failed {
+			return errors.New("some contracts could not be linked to the schema")
+		}
+		return nil
+	},
+}
+
+// jsonSchema is the subset of JSON Schema used to describe contracts.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	MinLength            int                    `json:"minLength,omitempty"`
+	MaxLength            int                    `json:"maxLength,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// contractSchema generates the JSON Schema of slc.Contract.
+func contractSchema(id string) *jsonSchema {
+	g := &schemaGenerator{defs: make(map[string]*jsonSchema), types: make(map[string]reflect.Type)}
+	root := g.schema(contractType)
+	root.Schema = jsonSchemaDialect
+	root.ID = id
+	root.Title = "Smart Legal Contract"
+	root.Defs = g.defs
+	return root
+}
+
+// schemaGenerator derives JSON Schemas from Go types. Structs are defined once in $defs and
+// referenced by name.
+type schemaGenerator struct {
+	defs  map[string]*jsonSchema
+	types map[string]reflect.Type
+}
+
+var (
+	durationType = reflect.TypeOf(metav1.Duration{})
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case durationType:
+		return &jsonSchema{Type: "string", Pattern: durationPattern}
+	case timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		return &jsonSchema{Ref: "#/$defs/" + g.define(t)}
+	}
+	// Interfaces and other kinds accept any value.
+	return &jsonSchema{}
+}
+
+// define adds a struct to $defs and returns its name, which is qualified by its package when
+// another package has a type of the same name.
+func (g *schemaGenerator) define(t reflect.Type) string {
+	name := t.Name()
+	if other, ok := g.types[name]; ok && other != t {
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+	}
+	if _, ok := g.types[name]; ok {
+		return name
+	}
+	g.types[name] = t
+	def := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	g.defs[name] = def
+	if doc, ok := schemaDocs[t.Name()]; ok {
+		def.Description = doc.Description
+	}
+	g.fields(def, t)
+	return name
+}
+
+// fields adds the properties of a struct to def. Fields of embedded structs are inlined, as
+// encoding/json does.
+func (g *schemaGenerator) fields(def *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(def, f.Type)
+			continue
+		}
+		key := fieldKey(f, "json")
+
+		s := g.schema(f.Type)
+		if doc, ok := schemaDocs[t.Name()+"."+f.Name]; ok {
+			s.Description = doc.Description
+			s.Enum = doc.Enum
+			s.MinLength = doc.MinLength
+			s.MaxLength = doc.MaxLength
+		}
+		validate := strings.Split(f.Tag.Get("validate"), ",")
+		if contains(validate, "url") {
+			s.Format = "uri"
+		}
+		def.Properties[key] = s
+
+		// Contract types mark required fields for the validator, while Kubernetes APIs make
+		// every field without omitempty required.
+		required := contains(validate, "required")
+		if t.PkgPath() != contractType.PkgPath() {
+			required = !strings.Contains(","+opts+",", ",omitempty,")
+		}
+		if required {
+			def.Required = append(def.Required, key)
+		}
+	}
+}
+
+// schemaPath returns the reference to the schema file from a contract, relative to the
+// contract's directory.
+func schemaPath(contract, schema string) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(contract))
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(schema)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
+
+// linkSchema references a schema from a contract file and reports whether the file changed.
+// An existing reference is replaced. JSON Schema documents, such as a schema generated
+// earlier, are left alone.
+func linkSchema(file, ref string) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	if isJSONSchemaDocument(data, contractFormat(file, data)) {
+		return false, nil
+	}
+	var linked []byte
+	switch format := contractFormat(file, data); format {
+	case "yaml", "toml":
+		directive := yamlSchemaModeline
+		if format == "toml" {
+			directive = tomlSchemaDirective
+		}
+		_, body := splitSchemaDirective(data, format)
+		linked = append([]byte(directive+ref+"\n"), body...)
+	case "json":
+		doc, err := decodeDocument(data, format)
+		if err != nil {
+			return false, err
+		}
+		fields, ok := doc.(yaml.MapSlice)
+		if !ok {
+			return false, errors.New("the contract is not a JSON object")
+		}
+		out := yaml.MapSlice{{Key: "$schema", Value: ref}}
+		for _, item := range fields {
+			if item.Key != "$schema" {
+				out = append(out, item)
+			}
+		}
+		var buf bytes.Buffer
+		if err := encodeDocument(&buf, out, format); err != nil {
+			return false, err
+		}
+		linked = buf.Bytes()
+	default:
+		return false, fmt.Errorf("%w: %s", ErrFormatNotSupported, format)
+	}
+	if bytes.Equal(data, linked) {
+		return false, nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, linked, info.Mode().Perm())
+}
+
+// isJSONSchemaDocument reports whether a document is itself a JSON Schema, i.e. its $schema
+// is a JSON Schema dialect rather than a reference to the contract schema.
+func isJSONSchemaDocument(data []byte, format string) bool {
+	if format != "json" && format != "yaml" {
+		return false
+	}
+	doc, err := decodeDocument(data, format)
+	if err != nil {
+		return false
+	}
+	fields, _ := doc.(yaml.MapSlice)
+	for _, item := range fields {
+		if item.Key == "$schema" {
+			dialect, _ := item.Value.(string)
+			return strings.HasPrefix(dialect, "https://json-schema.org/") || strings.HasPrefix(dialect, "http://json-schema.org/")
+		}
+	}
+	return false
+}
+
+// sameFile reports whether two paths name the same file.
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// splitSchemaDirective separates the schema modeline or directive on the first line of a YAML
+// or TOML contract from the rest of the document.
+func splitSchemaDirective(data []byte, format string) ([]byte, []byte) {
+	prefix := yamlSchemaModeline
+	if format == "toml" {
+		prefix = tomlSchemaDirective
+	}
+	if format != "yaml" && format != "toml" || !bytes.HasPrefix(data, []byte(prefix)) {
+		return nil, data
+	}
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 {
+		return data, nil
+	}
+	return data[:end+1], data[end+1:]
+}
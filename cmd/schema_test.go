@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestContractSchemaRequired(t *testing.T) {
+	s := contractSchema("https://example.com/contract.schema.json")
+	tests := []struct {
+		def  string
+		want []string
+	}{
+		// Contract types are required by their validate tags, even without omitempty.
+		{def: "Contract", want: []string{"version", "name", "source", "state"}},
+		{def: "GitSource", want: []string{"url"}},
+		{def: "State", want: []string{"name"}},
+		{def: "Transition", want: []string{"to", "on"}},
+		{def: "Action"},
+		// Kubernetes types are required unless they are omitempty.
+		{def: "KustomizationSpec", want: []string{"interval", "prune", "sourceRef"}},
+		{def: "Decryption", want: []string{"provider"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.def, func(t *testing.T) {
+			def, ok := s.Defs[tt.def]
+			if !ok {
+				t.Fatalf("contractSchema() has no definition of %s", tt.def)
+			}
+			if !reflect.DeepEqual(def.Required, tt.want) {
+				t.Errorf("%s required = %q, want %q", tt.def, def.Required, tt.want)
+			}
+			for _, key := range def.Required {
+				if _, ok := def.Properties[key]; !ok {
+					t.Errorf("%s requires %s, which is not a property", tt.def, key)
+				}
+			}
+		})
+	}
+	if got := s.Defs["GitSource"].Properties["url"].Format; got != "uri" {
+		t.Errorf("GitSource url format = %q, want uri", got)
+	}
+}
+
+type schemaEmbedded struct {
+	Inlined string `json:"inlined"`
+}
+
+type schemaFixture struct {
+	schemaEmbedded
+	Required string            `json:"required"`
+	Optional string            `json:"optional,omitempty"`
+	Skipped  string            `json:"-"`
+	Pointer  *schemaEmbedded   `json:"pointer,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	hidden   string
+}
+
+func TestSchemaGeneratorFields(t *testing.T) {
+	g := &schemaGenerator{defs: make(map[string]*jsonSchema), types: make(map[string]reflect.Type)}
+	g.schema(reflect.TypeOf(&schemaFixture{}))
+	def := g.defs["schemaFixture"]
+	if def == nil {
+		t.Fatal("schema() did not define schemaFixture")
+	}
+	var keys []string
+	for key := range def.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if want := []string{"inlined", "labels", "optional", "pointer", "required"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("properties = %q, want %q", keys, want)
+	}
+	if want := []string{"inlined", "required", "labels"}; !reflect.DeepEqual(def.Required, want) {
+		t.Errorf("required = %q, want %q", def.Required, want)
+	}
+	if got := def.Properties["pointer"].Ref; got != "#/$defs/schemaEmbedded" {
+		t.Errorf("pointer $ref = %q, want #/$defs/schemaEmbedded", got)
+	}
+	if got := def.Properties["labels"].AdditionalProperties; got == nil || got.Type != "string" {
+		t.Errorf("labels additionalProperties = %+v, want strings", got)
+	}
+}
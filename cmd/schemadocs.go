@@ -0,0 +1,104 @@
+package cmd
+
+// schemaDocs describes the contract types and fields in the generated JSON Schema, keyed by
+// type name or by type and field name. The Flux entries follow the Go doc comments and
+// kubebuilder markers of the Kustomization API.
+var schemaDocs = map[string]jsonSchema{
+	"Contract":                                 {Description: "A Smart Legal Contract: a state machine whose states apply Kubernetes workloads as the agreement progresses."},
+	"Contract.Version":                         {Description: "The version of the contract, e.g. v1.0.0."},
+	"Contract.Name":                            {Description: "The name of the contract."},
+	"Contract.Source":                          {Description: "The Git repository that holds the contract project."},
+	"Contract.Text":                            {Description: "The human-readable text of the agreement."},
+	"Contract.Policy":                          {Description: "The Git repository that holds the policies of the contract."},
+	"Contract.State":                           {Description: "The state machine of the contract."},
+	"GitSource.URL":                            {Description: "The URL of the Git repository."},
+	"GitSource.Branch":                         {Description: "The branch of the contract project."},
+	"GitSource.Path":                           {Description: "The path of the contract in the repository."},
+	"ContractText.URL":                         {Description: "The URL of the contract text."},
+	"PolicySource.URL":                         {Description: "The URL of the Git repository that holds the policies."},
+	"PolicySource.Branch":                      {Description: "The branch of the policies."},
+	"PolicySource.Directory":                   {Description: "The directory of the policies in the repository."},
+	"StateConfiguration.Initial":               {Description: "The name of the state that new instances of the contract start in."},
+	"StateConfiguration.URL":                   {Description: "The URL of the state configuration."},
+	"StateConfiguration.States":                {Description: "The states of the contract. Each state must have a unique name."},
+	"State.Name":                               {Description: "The unique name of the state."},
+	"State.Entry":                              {Description: "The action performed when the contract enters the state."},
+	"State.Exit":                               {Description: "The action performed when the contract leaves the state."},
+	"State.Transitions":                        {Description: "The transitions out of the state. The first transition whose event type matches and whose conditions hold is taken."},
+	"Action.ActionType":                        {Description: "The kind of action."},
+	"Action.KubernetesActions":                 {Description: "The Kustomizations the action applies."},
+	"KubernetesAction.Namespace":               {Description: "The namespace of the Kustomization."},
+	"KubernetesAction.KustomizationSpec":       {Description: "The Flux Kustomization to apply."},
+	"Transition.Name":                          {Description: "The name of the transition."},
+	"Transition.To":                            {Description: "The name of the state the transition leads to."},
+	"Transition.On":                            {Description: "The CloudEvent type that triggers the transition, e.g. com.example.contract.signed."},
+	"Transition.Conditions":                    {Description: "Conditions on the event that must all hold for the transition to be taken."},
+	"Condition.Name":                           {Description: "A dotted path into the CloudEvent, e.g. data.signature.validated."},
+	"Condition.Value":                          {Description: "The expected value at the path, written as a string. Booleans and numbers are compared by value, strings exactly."},
+	"KustomizationSpec":                        {Description: "The spec of a Flux Kustomization."},
+	"KustomizationSpec.CommonMetadata":         {Description: "CommonMetadata specifies the common labels and annotations that are applied to all resources. Any existing label or annotation will be overridden if its key matches a common one."},
+	"KustomizationSpec.DependsOn":              {Description: "DependsOn may contain a meta.NamespacedObjectReference slice with references to Kustomization resources that must be ready before this Kustomization can be reconciled."},
+	"KustomizationSpec.Decryption":             {Description: "Decrypt Kubernetes secrets before applying them on the cluster."},
+	"KustomizationSpec.Interval":               {Description: "The interval at which to reconcile the Kustomization. This interval is approximate and may be subject to jitter to ensure efficient use of resources."},
+	"KustomizationSpec.RetryInterval":          {Description: "The interval at which to retry a previously failed reconciliation. When not specified, the controller uses the KustomizationSpec.Interval value to retry failures."},
+	"KustomizationSpec.KubeConfig":             {Description: "The KubeConfig for reconciling the Kustomization on a remote cluster. When used in combination with KustomizationSpec.ServiceAccountName, forces the controller to act on behalf of that Service Account at the target cluster. If the --default-service-account flag is set, its value will be used as a controller level fallback for when KustomizationSpec.ServiceAccountName is empty."},
+	"KustomizationSpec.Path":                   {Description: "Path to the directory containing the kustomization.yaml file, or the set of plain YAMLs a kustomization.yaml should be generated for. Defaults to 'None', which translates to the root path of the SourceRef."},
+	"KustomizationSpec.PostBuild":              {Description: "PostBuild describes which actions to perform on the YAML manifest generated by building the kustomize overlay."},
+	"KustomizationSpec.Prune":                  {Description: "Prune enables garbage collection."},
+	"KustomizationSpec.DeletionPolicy":         {Description: "DeletionPolicy can be used to control garbage collection when this Kustomization is deleted. Valid values are ('MirrorPrune', 'Delete', 'Orphan'). 'MirrorPrune' mirrors the Prune field (orphan if false, delete if true). Defaults to 'MirrorPrune'.", Enum: deletionPolicies},
+	"KustomizationSpec.HealthChecks":           {Description: "A list of resources to be included in the health assessment."},
+	"KustomizationSpec.NamePrefix":             {Description: "NamePrefix will prefix the names of all managed resources.", MinLength: 1, MaxLength: 200},
+	"KustomizationSpec.NameSuffix":             {Description: "NameSuffix will suffix the names of all managed resources.", MinLength: 1, MaxLength: 200},
+	"KustomizationSpec.Patches":                {Description: "Strategic merge and JSON patches, defined as inline YAML objects, capable of targeting objects based on kind, label and annotation selectors."},
+	"KustomizationSpec.Images":                 {Description: "Images is a list of (image name, new name, new tag or digest) for changing image names, tags or digests. This can also be achieved with a patch, but this operator is simpler to specify."},
+	"KustomizationSpec.ServiceAccountName":     {Description: "The name of the Kubernetes service account to impersonate when reconciling this Kustomization."},
+	"KustomizationSpec.SourceRef":              {Description: "Reference of the source where the kustomization file is."},
+	"KustomizationSpec.Suspend":                {Description: "This flag tells the controller to suspend subsequent kustomize executions, it does not apply to already started executions. Defaults to false."},
+	"KustomizationSpec.TargetNamespace":        {Description: "TargetNamespace sets or overrides the namespace in the kustomization.yaml file.", MinLength: 1, MaxLength: 63},
+	"KustomizationSpec.Timeout":                {Description: "Timeout for validation, apply and health checking operations. Defaults to 'Interval' duration."},
+	"KustomizationSpec.Force":                  {Description: "Force instructs the controller to recreate resources when patching fails due to an immutable field change."},
+	"KustomizationSpec.Wait":                   {Description: "Wait instructs the controller to check the health of all the reconciled resources. When enabled, the HealthChecks are ignored. Defaults to false."},
+	"KustomizationSpec.Components":             {Description: "Components specifies relative paths to specifications of other Components."},
+	"KustomizationSpec.HealthCheckExprs":       {Description: "HealthCheckExprs is a list of healthcheck expressions for evaluating the health of custom resources using Common Expression Language (CEL). The expressions are evaluated only when Wait or HealthChecks are specified."},
+	"CommonMetadata.Annotations":               {Description: "Annotations to be added to the object's metadata."},
+	"CommonMetadata.Labels":                    {Description: "Labels to be added to the object's metadata."},
+	"Decryption.Provider":                      {Description: "Provider is the name of the decryption engine.", Enum: []string{"sops"}},
+	"Decryption.SecretRef":                     {Description: "The secret name containing the private OpenPGP keys used for decryption."},
+	"PostBuild.Substitute":                     {Description: "Substitute holds a map of key/value pairs. The variables defined in your YAML manifests that match any of the keys defined in the map will be substituted with the set value. Includes support for bash string replacement functions e.g. ${var:=default}, ${var:position} and ${var/substring/replacement}."},
+	"PostBuild.SubstituteFrom":                 {Description: "SubstituteFrom holds references to ConfigMaps and Secrets containing the variables and their values to be substituted in the YAML manifests. The ConfigMap and the Secret data keys represent the var names, and they must match the vars declared in the manifests for the substitution to happen."},
+	"SubstituteReference.Kind":                 {Description: "Kind of the values referent, valid values are ('Secret', 'ConfigMap').", Enum: []string{"Secret", "ConfigMap"}},
+	"SubstituteReference.Name":                 {Description: "Name of the values referent. Should reside in the same namespace as the referring resource.", MinLength: 1, MaxLength: 253},
+	"SubstituteReference.Optional":             {Description: "Optional indicates whether the referenced resource must exist, or whether to tolerate its absence. If true and the referenced resource is absent, proceed as if the resource was present but empty, without any variables defined."},
+	"CrossNamespaceSourceReference.APIVersion": {Description: "API version of the referent."},
+	"CrossNamespaceSourceReference.Kind":       {Description: "Kind of the referent.", Enum: []string{"OCIRepository", "GitRepository", "Bucket"}},
+	"CrossNamespaceSourceReference.Name":       {Description: "Name of the referent."},
+	"CrossNamespaceSourceReference.Namespace":  {Description: "Namespace of the referent, defaults to the namespace of the Kubernetes resource object that contains the reference."},
+	"LocalObjectReference.Name":                {Description: "Name of the referent."},
+	"NamespacedObjectReference.Name":           {Description: "Name of the referent."},
+	"NamespacedObjectReference.Namespace":      {Description: "Namespace of the referent, when not specified it acts as LocalObjectReference."},
+	"NamespacedObjectKindReference.APIVersion": {Description: "API version of the referent, if not specified the Kubernetes preferred version will be used."},
+	"NamespacedObjectKindReference.Kind":       {Description: "Kind of the referent."},
+	"NamespacedObjectKindReference.Name":       {Description: "Name of the referent."},
+	"NamespacedObjectKindReference.Namespace":  {Description: "Namespace of the referent, when not specified it acts as LocalObjectReference."},
+	"SecretKeyReference.Name":                  {Description: "Name of the Secret."},
+	"SecretKeyReference.Key":                   {Description: "Key in the Secret, when not specified an implementation-specific default key is used."},
+	"KubeConfigReference.SecretRef":            {Description: "SecretRef holds the name of a secret that contains a key with the kubeconfig file as the value. If no key is set, the key will default to 'value'. It is recommended that the kubeconfig is self-contained, and the secret is regularly updated if credentials such as a cloud-access-token expire. Cloud specific `cmd-path` auth helpers will not function without adding binaries and credentials to the Pod that is responsible for reconciling Kubernetes resources."},
+	"Image.Name":                               {Description: "Name is a tag-less image name."},
+	"Image.NewName":                            {Description: "NewName is the value used to replace the original name."},
+	"Image.NewTag":                             {Description: "NewTag is the value used to replace the original tag."},
+	"Image.Digest":                             {Description: "Digest is the value used to replace the original image tag. If digest is present NewTag value is ignored."},
+	"Selector.Group":                           {Description: "Group is the API group to select resources from. Together with Version and Kind it is capable of unambiguously identifying and/or selecting resources. https://github.com/kubernetes/community/blob/master/contributors/design-proposals/api-machinery/api-group.md"},
+	"Selector.Version":                         {Description: "Version of the API Group to select resources from. Together with Group and Kind it is capable of unambiguously identifying and/or selecting resources. https://github.com/kubernetes/community/blob/master/contributors/design-proposals/api-machinery/api-group.md"},
+	"Selector.Kind":                            {Description: "Kind of the API Group to select resources from. Together with Group and Version it is capable of unambiguously identifying and/or selecting resources. https://github.com/kubernetes/community/blob/master/contributors/design-proposals/api-machinery/api-group.md"},
+	"Selector.Namespace":                       {Description: "Namespace to select resources from."},
+	"Selector.Name":                            {Description: "Name to match resources with."},
+	"Selector.AnnotationSelector":              {Description: "AnnotationSelector is a string that follows the label selection expression https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#api It matches with the resource annotations."},
+	"Selector.LabelSelector":                   {Description: "LabelSelector is a string that follows the label selection expression https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#api It matches with the resource labels."},
+	"Patch.Patch":                              {Description: "Patch contains an inline StrategicMerge patch or an inline JSON6902 patch with an array of operation objects."},
+	"Patch.Target":                             {Description: "Target points to the resources that the patch document should be applied to."},
+	"CustomHealthCheck.APIVersion":             {Description: "APIVersion of the custom resource under evaluation."},
+	"CustomHealthCheck.Kind":                   {Description: "Kind of the custom resource under evaluation."},
+	"HealthCheckExpressions.Current":           {Description: "Current is the CEL expression that determines if the status of the custom resource has reached the desired state."},
+	"HealthCheckExpressions.InProgress":        {Description: "InProgress is the CEL expression that determines if the status of the custom resource has not yet reached the desired state."},
+	"HealthCheckExpressions.Failed":            {Description: "Failed is the CEL expression that determines if the status of the custom resource has failed to reach the desired state."},
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var ErrOutputNotSupported = errors.New("output format not supported")
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+	simulateCmd.Flags().StringP("events", "e", "", "A file of CloudEvents as a JSON array or NDJSON. Use - for stdin.")
+	simulateCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json")
+	_ = simulateCmd.MarkFlagRequired("events")
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate CONTRACT --events FILE",
+	Short: "Replay CloudEvents against a Smart Legal Contract",
+	Long: `Simulate a Smart Legal Contract locally by replaying CloudEvents against its state machine.
+
+The contract starts in its initial state. Each event whose type matches the "on" of a transition from the current state
+fires that transition when its conditions hold. The trace shows every state, transition and the Entry and Exit actions
+that a Network would perform. Nothing is deployed.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eventsFile, _ := cmd.Flags().GetString("events")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		events, err := readEventsFile(eventsFile, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		m, err := newMachine(c)
+		if err != nil {
+			return err
+		}
+
+		trace := []step{m.start()}
+		var runErr error
+		for _, e := range events {
+			s, err := m.fire(e)
+			if err != nil {
+				runErr = err
+				break
+			}
+			trace = append(trace, s)
+		}
+
+		if output == "json" {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(struct {
+				Contract string `json:"contract"`
+				Final    string `json:"final"`
+				Trace    []step `json:"trace"`
+			}{c.Name, m.current, trace}); err != nil {
+				return err
+			}
+			return runErr
+		}
+		printTrace(cmd.OutOrStdout(), c.Name, trace)
+		if runErr != nil {
+			return runErr
+		}
+		final := m.current
+		if m.terminal() {
+			final += " (terminal)"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s %s\n", style.Render("Final state:"), final)
+		return nil
+	},
+}
+
+// printTrace writes a simulation trace for humans.
+func printTrace(w io.Writer, name string, trace []step) {
+	for i, s := range trace {
+		if s.Event == nil {
+			fmt.Fprintf(w, "%s %s in %s\n", style.Render("Simulating"), name, successStyle.Render(s.To))
+		} else {
+			id := ""
+			if s.Event.ID != "" {
+				id = helpStyle(" id=" + s.Event.ID)
+			}
+			fmt.Fprintf(w, "\n%s %s%s\n", style.Render(fmt.Sprintf("#%d", i)), s.Event.Type, id)
+			if !s.Fired {
+				fmt.Fprintf(w, "  %s %s\n", warnStyle.Render("ignored"), s.Reason)
+				continue
+			}
+			fmt.Fprintf(w, "  %s %s %s\n", s.From, helpStyle("── "+s.Transition+" ─▶"), successStyle.Render(s.To))
+		}
+		for _, a := range s.Exit {
+			fmt.Fprintf(w, "  %s %s\n", helpStyle("exit "), a)
+		}
+		for _, a := range s.Entry {
+			fmt.Fprintf(w, "  %s %s\n", helpStyle("entry"), a)
+		}
+	}
+}
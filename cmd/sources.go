@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/decombine/slc"
+)
+
+// resolveSources enables checking Contract.Source and Contract.Policy against their
+// Git repositories during validation.
+var resolveSources bool
+
+// gitRepository is a Git repository that can be queried for branches and paths.
+type gitRepository struct {
+	// gitDir is passed to git with --git-dir so bare and working repositories are treated alike.
+	gitDir string
+	err    error
+}
+
+// sourceResolver opens each repository once per invocation, even when many contracts
+// share it.
+type sourceResolver struct {
+	mu    sync.Mutex
+	repos map[string]*repoOnce
+}
+
+type repoOnce struct {
+	once sync.Once
+	repo gitRepository
+}
+
+var defaultResolver = &sourceResolver{repos: make(map[string]*repoOnce)}
+
+// checkSources verifies that the branch and path of the contract's source and policy exist
+// in their repositories. Relative repository paths are resolved against baseDir.
+func checkSources(c *slc.Contract, baseDir string) []diagnostic {
+	var diags []diagnostic
+	diags = append(diags, defaultResolver.check(c.Source.URL, c.Source.Branch, c.Source.Path, "Source", "Path", baseDir)...)
+	diags = append(diags, defaultResolver.check(c.Policy.URL, c.Policy.Branch, c.Policy.Directory, "Policy", "Directory", baseDir)...)
+	return diags
+}
+
+func (r *sourceResolver) check(repoURL, branch, p, field, pathField, baseDir string) []diagnostic {
+	if repoURL == "" {
+		return nil
+	}
+	repo := r.open(repoURL, baseDir)
+	if repo.err != nil {
+		return []diagnostic{{
+			Severity: severityError,
+			Rule:     "source/repository",
+			Field:    field + ".URL",
+			Message:  fmt.Sprintf("cannot open repository %s: %v", repoURL, repo.err),
+		}}
+	}
+
+	rev := branch
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if rev != "HEAD" {
+		// Only branches are accepted, not tags or commits that happen to share the name.
+		rev = "refs/heads/" + rev
+	}
+	commit, err := repo.git("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil || commit == "" {
+		return []diagnostic{{
+			Severity: severityError,
+			Rule:     "source/branch",
+			Field:    field + ".Branch",
+			Message:  fmt.Sprintf("branch %q does not exist in %s", branch, repoURL),
+		}}
+	}
+
+	clean := strings.Trim(path.Clean("/"+filepath.ToSlash(p)), "/")
+	if clean == "" {
+		return nil
+	}
+	out, err := repo.git("ls-tree", "--name-only", commit, "--", clean)
+	if err != nil || out == "" {
+		return []diagnostic{{
+			Severity: severityError,
+			Rule:     "source/path",
+			Field:    field + "." + pathField,
+			Message:  fmt.Sprintf("path %q does not exist on branch %q of %s", p, strings.TrimPrefix(rev, "refs/heads/"), repoURL),
+		}}
+	}
+	return nil
+}
+
+// open returns the repository for repoURL. Local paths and file:// URLs are used in place;
+// remote repositories are cloned into the cache without file contents and fetched on reuse.
+func (r *sourceResolver) open(repoURL, baseDir string) gitRepository {
+	local := localRepositoryPath(repoURL, baseDir)
+	key := repoURL
+	if local != "" {
+		key = local
+	}
+
+	r.mu.Lock()
+	entry, ok := r.repos[key]
+	if !ok {
+		entry = &repoOnce{}
+		r.repos[key] = entry
+	}
+	r.mu.Unlock()
+
+	entry.once.Do(func() {
+		if local != "" {
+			entry.repo = openLocalRepository(local)
+			return
+		}
+		entry.repo = cloneRepository(repoURL)
+	})
+	return entry.repo
+}
+
+// localRepositoryPath returns the filesystem path of repoURL when it refers to a local
+// repository, or an empty string for remote URLs.
+func localRepositoryPath(repoURL, baseDir string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	if strings.Contains(repoURL, "://") || isSCPLikeURL(repoURL) {
+		return ""
+	}
+	if filepath.IsAbs(repoURL) {
+		return repoURL
+	}
+	return filepath.Join(baseDir, repoURL)
+}
+
+// isSCPLikeURL reports whether repoURL uses the scp-like syntax [user@]host:path, which git
+// recognizes by a colon before the first slash. A single letter before the colon is a
+// Windows drive.
+func isSCPLikeURL(repoURL string) bool {
+	i := strings.Index(repoURL, ":")
+	return i > 1 && !strings.Contains(repoURL[:i], "/")
+}
+
+func openLocalRepository(dir string) gitRepository {
+	out, err := runGit(dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return gitRepository{err: err}
+	}
+	return gitRepository{gitDir: out}
+}
+
+// cloneRepository maintains a bare, blob-less clone of repoURL under the config directory.
+func cloneRepository(repoURL string) gitRepository {
+	if strings.HasPrefix(repoURL, "-") {
+		return gitRepository{err: fmt.Errorf("invalid repository URL %q", repoURL)}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return gitRepository{err: err}
+	}
+	sum := sha256.Sum256([]byte(repoURL))
+	dir := filepath.Join(home, ConfigPath, "cache", "repos", hex.EncodeToString(sum[:8]))
+
+	if _, err := os.Stat(dir); err == nil {
+		repo := gitRepository{gitDir: dir}
+		if _, err := repo.git("fetch", "--prune", "--quiet", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+			return gitRepository{err: err}
+		}
+		return repo
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return gitRepository{err: err}
+	}
+	if _, err := runGit("", "clone", "--bare", "--quiet", "--filter=blob:none", "--no-tags", "--", repoURL, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return gitRepository{err: err}
+	}
+	return gitRepository{gitDir: dir}
+}
+
+func (r gitRepository) git(args ...string) (string, error) {
+	return runGit("", append([]string{"--git-dir", r.gitDir}, args...)...)
+}
+
+// runGit runs git in dir and returns its trimmed output, or an error that includes stderr.
+func runGit(dir string, args ...string) (string, error) {
+	out, err := gitOutput(dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitOutput runs git in dir and returns its output as is, or an error that includes stderr.
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Never prompt for credentials; private repositories must be configured ahead of time.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/decombine/slc"
+)
+
+// analyzeStateMachine performs a semantic validation pass over a StateConfiguration to find
+// problems the schema cannot express: unknown initial or target states, unreachable states,
+// states with no way out, duplicate state names and ambiguous transitions.
+//
+// A state without any transitions is considered terminal. A state that declares transitions
+// but cannot leave through any of them is reported because it traps the contract.
+func analyzeStateMachine(sc slc.StateConfiguration) []diagnostic {
+	var diags []diagnostic
+
+	index := make(map[string]int, len(sc.States))
+	for i, s := range sc.States {
+		if s.Name == "" {
+			continue
+		}
+		if _, ok := index[s.Name]; ok {
+			diags = append(diags, diagnostic{
+				Severity: severityError,
+				Rule:     "state/duplicate-name",
+				Field:    fmt.Sprintf("State.States[%d].Name", i),
+				Message:  fmt.Sprintf("state %q is defined more than once", s.Name),
+			})
+			continue
+		}
+		index[s.Name] = i
+	}
+
+	if sc.Initial != "" {
+		if _, ok := index[sc.Initial]; !ok {
+			diags = append(diags, diagnostic{
+				Severity: severityError,
+				Rule:     "state/undefined-initial",
+				Field:    "State.Initial",
+				Message:  fmt.Sprintf("initial state %q is not defined", sc.Initial),
+			})
+		}
+	}
+
+	for i, s := range sc.States {
+		exits, undefined := 0, 0
+		for j, t := range s.Transitions {
+			if t.To == "" {
+				continue
+			}
+			if _, ok := index[t.To]; !ok {
+				diags = append(diags, diagnostic{
+					Severity: severityError,
+					Rule:     "state/undefined-target",
+					Field:    fmt.Sprintf("State.States[%d].Transitions[%d].To", i, j),
+					Message:  fmt.Sprintf("transition targets undefined state %q", t.To),
+				})
+				undefined++
+				continue
+			}
+			if t.To != s.Name {
+				exits++
+			}
+		}
+		// Transitions to undefined states are meant as exits and are already reported.
+		if s.Name != "" && len(s.Transitions) > 0 && exits == 0 && undefined == 0 {
+			diags = append(diags, diagnostic{
+				Severity: severityError,
+				Rule:     "state/no-exit",
+				Field:    fmt.Sprintf("State.States[%d]", i),
+				Message:  fmt.Sprintf("state %q has no way out and is not terminal", s.Name),
+			})
+		}
+
+		for j, t := range s.Transitions {
+			if t.On == "" {
+				continue
+			}
+			if k := shadowingTransition(s.Transitions, j); k >= 0 {
+				diags = append(diags, diagnostic{
+					Severity: severityError,
+					Rule:     "state/duplicate-event",
+					Field:    fmt.Sprintf("State.States[%d].Transitions[%d].On", i, j),
+					Message:  fmt.Sprintf("event %q is already handled by transition %q whenever the conditions of %q hold", t.On, transitionName(s.Transitions[k]), transitionName(t)),
+				})
+			}
+		}
+	}
+
+	if _, ok := index[sc.Initial]; ok {
+		reachable := reachableStates(sc, index)
+		for i, s := range sc.States {
+			if s.Name == "" || index[s.Name] != i {
+				continue
+			}
+			if !reachable[s.Name] {
+				diags = append(diags, diagnostic{
+					Severity: severityWarning,
+					Rule:     "state/unreachable",
+					Field:    fmt.Sprintf("State.States[%d]", i),
+					Message:  fmt.Sprintf("state %q is not reachable from initial state %q", s.Name, sc.Initial),
+				})
+			}
+		}
+	}
+
+	return diags
+}
+
+// reachableStates walks the transitions of a StateConfiguration from its initial state and
+// returns the set of defined states that can be entered.
+func reachableStates(sc slc.StateConfiguration, index map[string]int) map[string]bool {
+	reachable := map[string]bool{sc.Initial: true}
+	queue := []string{sc.Initial}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, t := range sc.States[index[name]].Transitions {
+			if _, ok := index[t.To]; !ok || reachable[t.To] {
+				continue
+			}
+			reachable[t.To] = true
+			queue = append(queue, t.To)
+		}
+	}
+	return reachable
+}
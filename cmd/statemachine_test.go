@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func TestAnalyzeStateMachine(t *testing.T) {
+	to := func(name, target, on string) slc.Transition {
+		return slc.Transition{Name: name, To: target, On: on}
+	}
+	tests := []struct {
+		name  string
+		sc    slc.StateConfiguration
+		rules []string
+	}{
+		{
+			name: "valid",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("go", "B", "e")}},
+				{Name: "B"},
+			}},
+		},
+		{
+			name: "duplicate name",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A"},
+				{Name: "A"},
+			}},
+			rules: []string{"state/duplicate-name"},
+		},
+		{
+			name: "undefined initial",
+			sc: slc.StateConfiguration{Initial: "X", States: []slc.State{
+				{Name: "A"},
+			}},
+			rules: []string{"state/undefined-initial"},
+		},
+		{
+			name: "undefined target",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("go", "B", "e"), to("oops", "X", "f")}},
+				{Name: "B"},
+			}},
+			rules: []string{"state/undefined-target"},
+		},
+		{
+			name: "only undefined targets is not also no-exit",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("oops", "X", "e")}},
+			}},
+			rules: []string{"state/undefined-target"},
+		},
+		{
+			name: "undefined target beside a self-loop",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("stay", "A", "e"), to("oops", "X", "f")}},
+			}},
+			rules: []string{"state/undefined-target"},
+		},
+		{
+			name: "no exit",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("stay", "A", "e")}},
+			}},
+			rules: []string{"state/no-exit"},
+		},
+		{
+			name: "duplicate event",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{to("go", "B", "e"), to("again", "B", "e")}},
+				{Name: "B"},
+			}},
+			rules: []string{"state/duplicate-event"},
+		},
+		{
+			name: "same event with more conditions first",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					{Name: "guarded", To: "B", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "true"}}},
+					to("go", "B", "e"),
+				}},
+				{Name: "B"},
+			}},
+		},
+		{
+			name: "same event with more conditions after",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					to("go", "B", "e"),
+					{Name: "guarded", To: "B", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "true"}}},
+				}},
+				{Name: "B"},
+			}},
+			rules: []string{"state/duplicate-event"},
+		},
+		{
+			name: "same conditions in another order",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					{Name: "go", To: "B", On: "e", Conditions: []slc.Condition{{Name: "x", Value: "1"}, {Name: "y", Value: "2"}}},
+					{Name: "again", To: "B", On: "e", Conditions: []slc.Condition{{Name: "y", Value: "2"}, {Name: "x", Value: "1"}}},
+				}},
+				{Name: "B"},
+			}},
+			rules: []string{"state/duplicate-event"},
+		},
+		{
+			name: "same event with other conditions",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A", Transitions: []slc.Transition{
+					{Name: "yes", To: "B", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "true"}}},
+					{Name: "no", To: "B", On: "e", Conditions: []slc.Condition{{Name: "data.ok", Value: "false"}}},
+				}},
+				{Name: "B"},
+			}},
+		},
+		{
+			name: "unreachable",
+			sc: slc.StateConfiguration{Initial: "A", States: []slc.State{
+				{Name: "A"},
+				{Name: "B"},
+			}},
+			rules: []string{"state/unreachable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []string
+			for _, d := range analyzeStateMachine(tt.sc) {
+				rules = append(rules, d.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("analyzeStateMachine() rules = %q, want %q", rules, tt.rules)
+			}
+		})
+	}
+}
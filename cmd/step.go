@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/decombine/contract/pkg/condition"
+	"github.com/decombine/slc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(stepCmd)
+	stepCmd.Flags().StringP("state", "s", "", "Start in this state instead of the initial state")
+}
+
+var stepCmd = &cobra.Command{
+	Use:   "step CONTRACT",
+	Short: "Step through a Smart Legal Contract interactively",
+	Long: `Step through the state machine of a Smart Legal Contract one event at a time.
+
+The stepper shows the current state and the transitions that leave it, with their conditions. Each transition
+comes with an example CloudEvent that satisfies its conditions; edit it with $EDITOR to see what happens when they
+do not hold. Firing an event shows the Exit and Entry actions that the contract would perform on a Network.
+Steps can be undone, and the history of the session is kept on screen.
+
+No actions are performed.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, _ := cmd.Flags().GetString("state")
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		model, err := newStepModel(c, state)
+		if err != nil {
+			return err
+		}
+		_, err = tea.NewProgram(model, tea.WithAltScreen()).Run()
+		return err
+	},
+}
+
+// examplePayload returns a CloudEvent for a transition that satisfies its conditions.
+func examplePayload(t slc.Transition) string {
+	doc := map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "step",
+		"source":      "contract-step",
+		"type":        t.On,
+	}
+	for _, c := range t.Conditions {
+		var value interface{} = c.Value
+		if json.Valid([]byte(c.Value)) {
+			value, _ = condition.Decode([]byte(c.Value))
+		}
+		setPath(doc, c.Name, value)
+	}
+	data, _ := json.MarshalIndent(doc, "", "  ")
+	return string(data)
+}
+
+// setPath sets the value at a dotted path, creating objects on the way.
+func setPath(doc map[string]interface{}, path string, value interface{}) {
+	segments := strings.Split(path, ".")
+	for _, seg := range segments[:len(segments)-1] {
+		next, ok := doc[seg].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			doc[seg] = next
+		}
+		doc = next
+	}
+	doc[segments[len(segments)-1]] = value
+}
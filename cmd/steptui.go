@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/decombine/contract/pkg/condition"
+	"github.com/decombine/slc"
+)
+
+var paneStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(contractBlue).
+	Padding(0, 1)
+
+// stepEntry is one event delivered during a stepping session.
+type stepEntry struct {
+	From string
+	Step step
+}
+
+// editorFinishedMsg carries a payload back from $EDITOR.
+type editorFinishedMsg struct {
+	key     [2]int
+	payload string
+	err     error
+}
+
+type stepModel struct {
+	contract *slc.Contract
+	machine  *machine
+	cursor   int
+	// payloads holds edited events by state and transition index.
+	payloads map[[2]int]string
+	history  []stepEntry
+	// state is the state the session starts in, or empty for the initial state.
+	state   string
+	started step
+	err     error
+	width   int
+}
+
+func newStepModel(c *slc.Contract, state string) (stepModel, error) {
+	m, err := newMachine(c)
+	if err != nil {
+		return stepModel{}, err
+	}
+	model := stepModel{contract: c, machine: m, payloads: make(map[[2]int]string), state: state}
+	if err := model.restart(); err != nil {
+		return stepModel{}, err
+	}
+	return model, nil
+}
+
+// restart clears the history and starts the machine again.
+func (m *stepModel) restart() error {
+	m.history = nil
+	m.cursor = 0
+	m.started = m.machine.start()
+	if m.state != "" {
+		if err := m.machine.enter(m.state); err != nil {
+			return err
+		}
+		m.started = step{To: m.state, Fired: true}
+	}
+	return nil
+}
+
+func (m stepModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m stepModel) current() (int, *slc.State) {
+	i := m.machine.states[m.machine.current]
+	return i, &m.contract.State.States[i]
+}
+
+// payload returns the event that will be sent for the selected transition.
+func (m stepModel) payload() (key [2]int, payload string, ok bool) {
+	i, s := m.current()
+	if m.cursor >= len(s.Transitions) {
+		return key, "", false
+	}
+	key = [2]int{i, m.cursor}
+	if p, edited := m.payloads[key]; edited {
+		return key, p, true
+	}
+	return key, examplePayload(s.Transitions[m.cursor]), true
+}
+
+func (m stepModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+	case editorFinishedMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.payloads[msg.key] = msg.payload
+		}
+		return m, nil
+	case tea.KeyMsg:
+		m.err = nil
+		_, s := m.current()
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(s.Transitions)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			m.fire()
+		case "e":
+			if key, p, ok := m.payload(); ok {
+				return m, editPayload(key, p)
+			}
+		case "d":
+			if key, _, ok := m.payload(); ok {
+				delete(m.payloads, key)
+			}
+		case "u", "backspace":
+			m.undo()
+		case "r":
+			m.err = m.restart()
+		}
+	}
+	return m, nil
+}
+
+// fire sends the payload of the selected transition to the machine.
+func (m *stepModel) fire() {
+	_, p, ok := m.payload()
+	if !ok {
+		return
+	}
+	events, err := parseEvents([]byte(p))
+	if err != nil {
+		m.err = err
+		return
+	}
+	if len(events) != 1 {
+		m.err = fmt.Errorf("%w: the payload must be a single event", ErrInvalidEvent)
+		return
+	}
+	from := m.machine.current
+	s, err := m.machine.fire(events[0])
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.history = append(m.history, stepEntry{From: from, Step: s})
+	if s.Fired {
+		m.cursor = 0
+	}
+}
+
+// undo returns the machine to the state it was in before the last event.
+func (m *stepModel) undo() {
+	if len(m.history) == 0 {
+		m.err = errors.New("nothing to undo")
+		return
+	}
+	last := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	if err := m.machine.enter(last.From); err != nil {
+		m.err = err
+		return
+	}
+	m.cursor = 0
+}
+
+// editPayload opens a payload in $VISUAL or $EDITOR, falling back to vi.
+func editPayload(key [2]int, payload string) tea.Cmd {
+	f, err := os.CreateTemp("", "contract-event-*.json")
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	name := f.Name()
+	_, err = f.WriteString(payload + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name)
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := append(strings.Fields(editor), name)
+	return tea.ExecProcess(exec.Command(args[0], args[1:]...), func(err error) tea.Msg {
+		defer os.Remove(name)
+		if err != nil {
+			return editorFinishedMsg{key: key, err: err}
+		}
+		data, err := os.ReadFile(name)
+		return editorFinishedMsg{key: key, payload: strings.TrimSpace(string(data)), err: err}
+	})
+}
+
+func (m stepModel) View() string {
+	width := m.width
+	if width == 0 {
+		width = 100
+	}
+	left := width*3/5 - 4
+	right := width - left - 8
+
+	i, s := m.current()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", style.Render("Contract"), m.contract.Name)
+	fmt.Fprintf(&b, "%s %s\n\n", style.Render("State   "), successStyle.Render(s.Name))
+
+	var doc interface{}
+	_, p, ok := m.payload()
+	if ok {
+		if events, err := parseEvents([]byte(p)); err == nil && len(events) == 1 {
+			doc = events[0].document()
+		}
+	}
+	if len(s.Transitions) == 0 {
+		b.WriteString(helpStyle(fmt.Sprintf("%s is a terminal state.", s.Name)) + "\n")
+	}
+	for j, t := range s.Transitions {
+		cursor := "  "
+		if j == m.cursor {
+			cursor = style.Render("> ")
+		}
+		fmt.Fprintf(&b, "%s%s %s %s\n", cursor, transitionName(t), helpStyle("─▶ "+t.To), helpStyle("on "+t.On))
+		if j != m.cursor {
+			continue
+		}
+		for _, c := range t.Conditions {
+			if doc == nil {
+				fmt.Fprintf(&b, "    %s = %q\n", c.Name, c.Value)
+				continue
+			}
+			r := condition.Evaluate(c, doc)
+			if r.Holds {
+				fmt.Fprintf(&b, "    %s %s = %q\n", successStyle.Render("✓"), c.Name, c.Value)
+			} else {
+				fmt.Fprintf(&b, "    %s %s\n", ErrStyle.Render("✗"), r.Reason())
+			}
+		}
+	}
+	if ok {
+		label := "Payload"
+		if _, edited := m.payloads[[2]int{i, m.cursor}]; edited {
+			label += " (edited)"
+		}
+		fmt.Fprintf(&b, "\n%s\n%s\n", style.Render(label), textStyle(p))
+	}
+	main := paneStyle.Width(left).Render(strings.TrimRight(b.String(), "\n"))
+
+	b.Reset()
+	b.WriteString(style.Render("Actions") + "\n")
+	last := m.started
+	if len(m.history) > 0 {
+		last = m.history[len(m.history)-1].Step
+	}
+	if !last.Fired {
+		fmt.Fprintf(&b, "%s %s\n", warnStyle.Render("ignored"), last.Reason)
+	} else {
+		if last.From != "" {
+			fmt.Fprintf(&b, "%s %s %s\n", last.From, helpStyle("── "+last.Transition+" ─▶"), last.To)
+		}
+		for _, a := range last.Exit {
+			fmt.Fprintf(&b, "exit:  %s\n", a)
+		}
+		for _, a := range last.Entry {
+			fmt.Fprintf(&b, "entry: %s\n", a)
+		}
+		if len(last.Exit)+len(last.Entry) == 0 {
+			b.WriteString(helpStyle("no actions") + "\n")
+		}
+	}
+	b.WriteString("\n" + style.Render("History") + "\n")
+	fmt.Fprintf(&b, "%s %s\n", helpStyle(" 0."), m.started.To)
+	for n, h := range m.history {
+		if h.Step.Fired {
+			fmt.Fprintf(&b, "%s %s %s %s\n", helpStyle(fmt.Sprintf("%2d.", n+1)), h.From, helpStyle("── "+h.Step.Transition+" ─▶"), h.Step.To)
+		} else {
+			fmt.Fprintf(&b, "%s %s %s\n", helpStyle(fmt.Sprintf("%2d.", n+1)), h.Step.Event.Type, warnStyle.Render("ignored"))
+		}
+	}
+	side := paneStyle.Width(right).Render(strings.TrimRight(b.String(), "\n"))
+
+	view := lipgloss.JoinHorizontal(lipgloss.Top, main, " ", side) + "\n"
+	if m.err != nil {
+		view += ErrStyle.Render(m.err.Error()) + "\n"
+	}
+	return view + helpStyle("↑/↓ select • enter fire • e edit payload • d default payload • u undo • r restart • q quit") + "\n"
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// stdinName is used in place of a file name for contracts read from stdin.
+const stdinName = "stdin"
+
+var ErrNoInput = errors.New("no contracts to validate: pass a file, directory, glob or URL, or pipe a contract to stdin")
+
+// yamlDocumentMarker matches the lines that separate or terminate documents in a YAML stream.
+var yamlDocumentMarker = regexp.MustCompile(`^(---|\.\.\.)(\s.*)?$`)
+
+// contractDocument is a single Smart Legal Contract within a stream of contracts.
+type contractDocument struct {
+	Index  int
+	Format string
+	// Line is the line of the stream on which the document starts.
+	Line int
+	// Column is the column of Line at which the document starts, for JSON objects that
+	// follow another on the same line. Zero means the start of the line.
+	Column int
+	Data   []byte
+}
+
+// position moves diagnostics located within the document to their place in the stream.
+func (doc contractDocument) position(diags []diagnostic) {
+	for i := range diags {
+		if diags[i].Span.Line == 1 && doc.Column > 1 {
+			diags[i].Span.Column += doc.Column - 1
+		}
+		if diags[i].Span.Line > 0 {
+			diags[i].Span.Line += doc.Line - 1
+		}
+	}
+}
+
+// readStdin reads all of stdin, refusing to block on an interactive terminal.
+func readStdin(in io.Reader) ([]byte, error) {
+	if f, ok := in.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return nil, ErrNoInput
+		}
+	}
+	return io.ReadAll(in)
+}
+
+// isPathList reports whether piped input is a list of contract locations, e.g. the output of
+// find, rather than the contents of a contract. Paths with a contract extension count even
+// when they do not exist, so that they are reported as missing rather than decoded.
+func isPathList(data []byte) bool {
+	fields := strings.Fields(string(data))
+	// Compact JSON objects have no spaces and brackets that look like glob patterns.
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "{") {
+		return false
+	}
+	for _, f := range fields {
+		if strings.HasPrefix(f, "-") || isContractURL(f) {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil && !isGlob(f) && !hasContractExtension(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitContractStream splits piped content into individual contract documents. Concatenated
+// or newline-delimited JSON objects and "---" separated YAML streams are supported; anything
+// else is treated as a single document.
+func splitContractStream(data []byte) []contractDocument {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if trimmed[0] == '{' {
+		return splitJSONStream(data)
+	}
+	if format := contractFormat("", data); format != "yaml" {
+		return []contractDocument{{Format: format, Line: 1, Data: data}}
+	}
+	return splitYAMLStream(data)
+}
+
+// splitJSONStream splits a stream of JSON objects, such as NDJSON. Content that cannot be
+// decoded becomes the final document so its error is reported.
+func splitJSONStream(data []byte) []contractDocument {
+	var docs []contractDocument
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var offset int64
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		start := offset + int64(len(data[offset:])-len(bytes.TrimLeft(data[offset:], " \t\r\n")))
+		column := int(start) - (bytes.LastIndexByte(data[:start], '\n') + 1) + 1
+		if err != nil {
+			docs = append(docs, contractDocument{Format: "json", Line: lineAt(data, start), Column: column, Data: data[start:]})
+			break
+		}
+		offset = dec.InputOffset()
+		docs = append(docs, contractDocument{Format: "json", Line: lineAt(data, start), Column: column, Data: data[start:offset]})
+	}
+	for i := range docs {
+		docs[i].Index = i
+	}
+	return docs
+}
+
+// splitYAMLStream splits a YAML stream on its document markers, skipping empty documents.
+func splitYAMLStream(data []byte) []contractDocument {
+	var docs []contractDocument
+	lines := strings.SplitAfter(string(data), "\n")
+	start := 0
+	flush := func(end int) {
+		doc := strings.Join(lines[start:end], "")
+		if hasYAMLContent(doc) {
+			docs = append(docs, contractDocument{Index: len(docs), Format: "yaml", Line: start + 1, Data: []byte(doc)})
+		}
+	}
+	for i, line := range lines {
+		if yamlDocumentMarker.MatchString(strings.TrimRight(line, "\r\n")) {
+			flush(i)
+			// Keep the marker line blank so positions within the document are unchanged.
+			lines[i] = "\n"
+			start = i
+		}
+	}
+	flush(len(lines))
+	return docs
+}
+
+// hasYAMLContent reports whether a YAML document contains anything other than comments.
+func hasYAMLContent(doc string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
+
+func lineAt(data []byte, offset int64) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// validateStream validates every contract document in piped content. Each result is named by
+// its document index and its diagnostics are positioned within the whole stream.
+func validateStream(name string, data []byte) []contractResult {
+	docs := splitContractStream(data)
+	if len(docs) == 0 {
+		return []contractResult{failedResult(name, "source/read", ErrNoInput)}
+	}
+	var results []contractResult
+	for _, doc := range docs {
+		start := time.Now()
+		diags := checkContract(name, doc.Data, doc.Format)
+		doc.position(diags)
+		results = append(results, contractResult{
+			Name:        fmt.Sprintf("%s[%d]", name, doc.Index),
+			Format:      doc.Format,
+			Valid:       !hasErrors(diags),
+			Diagnostics: diags,
+			Duration:    time.Since(start).Seconds(),
+			data:        data,
+		})
+	}
+	return results
+}
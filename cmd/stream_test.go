@@ -0,0 +1,39 @@
+package cmd
+
+import "testing"
+
+func TestIsPathList(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{name: "existing file", in: "stream_test.go\n", want: true},
+		{name: "missing contract", in: "contracts/missing.yaml\n", want: true},
+		{name: "glob", in: "contracts/*.yaml", want: true},
+		{name: "URL", in: "https://example.com/contract.yaml", want: true},
+		{name: "YAML", in: "name: contract.yaml\n", want: false},
+		{name: "compact JSON", in: `{"states":[{"name":"A"}]}`, want: false},
+		{name: "empty", in: "\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPathList([]byte(tt.in)); got != tt.want {
+				t.Errorf("isPathList(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitJSONStream(t *testing.T) {
+	docs := splitJSONStream([]byte("{\"a\": 1} {\"b\": 2}\n  {\"c\": 3}\n"))
+	want := []struct{ line, column int }{{1, 1}, {1, 10}, {2, 3}}
+	if len(docs) != len(want) {
+		t.Fatalf("splitJSONStream() = %d documents, want %d", len(docs), len(want))
+	}
+	for i, w := range want {
+		if docs[i].Line != w.line || docs[i].Column != w.column {
+			t.Errorf("document %d starts at %d:%d, want %d:%d", i, docs[i].Line, docs[i].Column, w.line, w.column)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/decombine/slc"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+// defaultTestDir is where contract test files live in a project created by init project.
+const defaultTestDir = "contracts/tests"
+
+// testClockStart is the time at which the clock of every test case starts, so that event
+// times are reproducible.
+var testClockStart = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	ErrNoTestFiles = errors.New("no contract test files found")
+	ErrTestsFailed = errors.New("one or more contract tests failed")
+)
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+	testCmd.Flags().String("junit", "", "Write a JUnit XML report to this file")
+	testCmd.Flags().StringP("run", "r", "", "Only run test cases whose name contains this text")
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [files or directories...]",
+	Short: "Run the test suites of Smart Legal Contracts",
+	Long: `Run YAML test suites against the state machine of a Smart Legal Contract.
+
+Without arguments, the test files in contracts/tests are run. Each file names the contract it tests, relative to the
+file, and a list of cases. A case may start in any state, sends a sequence of CloudEvents and states what it expects:
+
+  contract: ../contract.yaml
+  tests:
+    - name: a validated signature starts the work
+      start: Draft
+      events:
+        - type: com.decombine.signature.sign
+          data:
+            signature:
+              validated: true
+      expect:
+        state: In Process
+        transitions: [Signing]
+        actions:
+          - exit: Draft
+          - entry: In Process
+
+Transitions and actions are only compared when they are listed. Actions name the states whose Exit or Entry
+action ran, in order.
+
+Files may declare deadlines, as for contract timeline. Events may then carry an "at" offset such as 10d, and a case
+may "advance" the clock after its events to let deadlines fall due.`,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		junitFile, _ := cmd.Flags().GetString("junit")
+		filter, _ := cmd.Flags().GetString("run")
+
+		files, err := findTestFiles(args)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		var suites []testSuiteResult
+		var passed, failed int
+		for _, f := range files {
+			suite := runTestFile(f, filter)
+			suites = append(suites, suite)
+			printTestSuite(out, suite)
+			for _, r := range suite.Results {
+				if r.passed() {
+					passed++
+				} else {
+					failed++
+				}
+			}
+			if suite.Err != nil {
+				failed++
+			}
+		}
+
+		status := successStyle.Render(fmt.Sprintf("%d passed", passed))
+		if failed > 0 {
+			status += ", " + ErrStyle.Render(fmt.Sprintf("%d failed", failed))
+		}
+		fmt.Fprintf(out, "\n%s %d test(s): %s\n", style.Render("Ran"), passed+failed, status)
+
+		if junitFile != "" {
+			if err := writeTestJUnit(junitFile, suites); err != nil {
+				return fmt.Errorf("error writing JUnit report: %w", err)
+			}
+		}
+		if failed > 0 {
+			return ErrTestsFailed
+		}
+		return nil
+	},
+}
+
+// testFile is a suite of test cases for one contract.
+type testFile struct {
+	// Contract is the path of the contract under test, relative to the test file.
+	Contract string `yaml:"contract,omitempty"`
+	// Deadlines emit time events as the clock of each case advances.
+	Deadlines []deadline `yaml:"deadlines,omitempty"`
+	Tests     []testCase `yaml:"tests"`
+}
+
+type testCase struct {
+	Name string `yaml:"name"`
+	// Start is the state the case begins in. The initial state is entered when it is empty.
+	Start  string                   `yaml:"start,omitempty"`
+	Events []map[string]interface{} `yaml:"events"`
+	// Advance moves the clock forward after the events, e.g. 30d, firing deadlines that fall due.
+	Advance string          `yaml:"advance,omitempty"`
+	Expect  testExpectation `yaml:"expect"`
+}
+
+// testExpectation is what a test case expects after its events. Nil lists are not checked.
+type testExpectation struct {
+	State       string        `yaml:"state,omitempty"`
+	Transitions *[]string     `yaml:"transitions,omitempty"`
+	Actions     *[]testAction `yaml:"actions,omitempty"`
+}
+
+// testAction names a state whose Entry or Exit action ran.
+type testAction struct {
+	Entry string `yaml:"entry,omitempty"`
+	Exit  string `yaml:"exit,omitempty"`
+}
+
+func (a testAction) String() string {
+	if a.Exit != "" {
+		return "exit: " + a.Exit
+	}
+	return "entry: " + a.Entry
+}
+
+type testSuiteResult struct {
+	File     string
+	Contract string
+	Results  []testCaseResult
+	// Err is set when the suite could not be run at all.
+	Err error
+}
+
+type testCaseResult struct {
+	Name     string
+	Duration time.Duration
+	// Failures describe each difference from the expectation.
+	Failures []string
+	Err      error
+}
+
+func (r testCaseResult) passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// testSuiteKey matches the top-level tests key that every test file has.
+var testSuiteKey = regexp.MustCompile(`(?m)^tests\s*:`)
+
+// findTestFiles resolves the arguments into YAML test files. Files named explicitly are always
+// run, while directories are searched for YAML files with a top-level tests key so contracts
+// and workloads next to the tests are skipped.
+func findTestFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{defaultTestDir}
+	}
+	var files []string
+	for _, arg := range args {
+		paths := []string{arg}
+		if isGlob(arg) {
+			matches, err := globContracts(arg)
+			if err != nil {
+				return nil, err
+			}
+			paths = matches
+		}
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrNoTestFiles, err)
+			}
+			if !info.IsDir() {
+				files = append(files, p)
+				continue
+			}
+			err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				ext := strings.ToLower(filepath.Ext(path))
+				if d.IsDir() || (ext != ".yaml" && ext != ".yml") {
+					return nil
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if testSuiteKey.Match(data) {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoTestFiles, strings.Join(args, ", "))
+	}
+	return files, nil
+}
+
+// runTestFile runs every case in a test file whose name contains filter.
+func runTestFile(path, filter string) testSuiteResult {
+	suite := testSuiteResult{File: path}
+	tf, contractPath, err := readTestFile(path)
+	if err != nil {
+		suite.Err = err
+		return suite
+	}
+	suite.Contract = contractPath
+	c, err := loadContract(suite.Contract)
+	if err != nil {
+		suite.Err = fmt.Errorf("error loading contract: %w", err)
+		return suite
+	}
+
+	for _, tc := range tf.Tests {
+		if filter != "" && !strings.Contains(tc.Name, filter) {
+			continue
+		}
+		start := time.Now()
+		r := runTestCase(c, tf.Deadlines, tc, nil)
+		r.Duration = time.Since(start)
+		suite.Results = append(suite.Results, r)
+	}
+	return suite
+}
+
+// readTestFile decodes a test file and resolves the location of the contract it tests.
+func readTestFile(path string) (testFile, string, error) {
+	var tf testFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tf, "", err
+	}
+	if err := yaml.Unmarshal(data, &tf); err != nil {
+		return tf, "", fmt.Errorf("error decoding test file: %w", err)
+	}
+	contractPath := tf.Contract
+	if contractPath == "" {
+		contractPath = defaultTestContract(filepath.Dir(path))
+	} else if !isContractURL(contractPath) && !filepath.IsAbs(contractPath) {
+		contractPath = filepath.Join(filepath.Dir(path), contractPath)
+	}
+	return tf, contractPath, nil
+}
+
+// defaultTestContract finds the contract in the directory above a tests directory.
+func defaultTestContract(dir string) string {
+	parent := filepath.Dir(dir)
+	for _, name := range []string{"contract.yaml", "contract.yml", "contract.json", "contract.toml"} {
+		if _, err := os.Stat(filepath.Join(parent, name)); err == nil {
+			return filepath.Join(parent, name)
+		}
+	}
+	return filepath.Join(parent, "contract.yaml")
+}
+
+// runTestCase replays the events of a case and compares the outcome with its expectation.
+// The observer, if any, is attached to the machine that runs the case.
+func runTestCase(c *slc.Contract, deadlines []deadline, tc testCase, observer machineObserver) testCaseResult {
+	r := testCaseResult{Name: tc.Name}
+	m, err := newMachine(c)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	m.observer = observer
+	vc, err := newVirtualClock(m, testClockStart, deadlines)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	var transitions []string
+	var actions []testAction
+	record := func(s step) {
+		if s.Transition != "" {
+			transitions = append(transitions, s.Transition)
+		}
+		if len(s.Exit) > 0 {
+			actions = append(actions, testAction{Exit: s.From})
+		}
+		if len(s.Entry) > 0 {
+			actions = append(actions, testAction{Entry: s.To})
+		}
+	}
+	first, err := vc.begin(tc.Start)
+	if err != nil {
+		r.Err = fmt.Errorf("start: %w", err)
+		return r
+	}
+	if first != nil {
+		record(first.step)
+	}
+
+	scheduled, err := scheduleEvents(vc, tc.Name, tc.Events)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	for i, te := range scheduled {
+		steps, err := vc.advanceTo(te.At)
+		for _, s := range steps {
+			record(s.step)
+		}
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		s, err := vc.deliver(te.Event)
+		if err != nil {
+			r.Err = fmt.Errorf("event %d: %w", i+1, err)
+			return r
+		}
+		record(s.step)
+	}
+	if tc.Advance != "" {
+		d, err := parseDuration(tc.Advance)
+		if err != nil {
+			r.Err = fmt.Errorf("advance: %w", err)
+			return r
+		}
+		steps, err := vc.advanceTo(vc.now.Add(d))
+		for _, s := range steps {
+			record(s.step)
+		}
+		if err != nil {
+			r.Err = err
+			return r
+		}
+	}
+
+	if tc.Expect.State != "" && tc.Expect.State != m.current {
+		r.Failures = append(r.Failures, fmt.Sprintf("final state:\n- %s\n+ %s", tc.Expect.State, m.current))
+	}
+	if tc.Expect.Transitions != nil {
+		if d, ok := diffLines(*tc.Expect.Transitions, transitions); !ok {
+			r.Failures = append(r.Failures, "transitions:\n"+d)
+		}
+	}
+	if tc.Expect.Actions != nil {
+		var want, got []string
+		for _, a := range *tc.Expect.Actions {
+			want = append(want, a.String())
+		}
+		for _, a := range actions {
+			got = append(got, a.String())
+		}
+		if d, ok := diffLines(want, got); !ok {
+			r.Failures = append(r.Failures, "actions:\n"+d)
+		}
+	}
+	return r
+}
+
+// testEvent converts an event written in YAML into a CloudEvent, filling in the attributes
+// that tests rarely care about.
+func testEvent(name string, i int, fields map[string]interface{}) (cloudEvent, error) {
+	var e cloudEvent
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return e, fmt.Errorf("event %d: %w", i+1, err)
+	}
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return e, fmt.Errorf("%w: event %d: %v", ErrInvalidEvent, i+1, err)
+	}
+	if e.Type == "" {
+		return e, fmt.Errorf("%w: event %d: missing type", ErrInvalidEvent, i+1)
+	}
+	if e.SpecVersion == "" {
+		e.SpecVersion = "1.0"
+	}
+	if e.ID == "" {
+		e.ID = fmt.Sprintf("%s/%d", name, i+1)
+	}
+	if e.Source == "" {
+		e.Source = "contract-test"
+	}
+	return e, nil
+}
+
+// diffLines renders a line diff of want and got, marking missing lines with "- " and unexpected
+// lines with "+ ". It reports whether the lists are equal.
+func diffLines(want, got []string) (string, bool) {
+	// Longest common subsequence table.
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	equal := true
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			b.WriteString("  " + want[i] + "\n")
+			i++
+			j++
+		case j < len(got) && (i == len(want) || lcs[i][j+1] >= lcs[i+1][j]):
+			b.WriteString("+ " + got[j] + "\n")
+			equal = false
+			j++
+		default:
+			b.WriteString("- " + want[i] + "\n")
+			equal = false
+			i++
+		}
+	}
+	if len(want) == 0 && len(got) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), equal
+}
+
+func printTestSuite(w io.Writer, suite testSuiteResult) {
+	fmt.Fprintln(w, style.Render(suite.File))
+	if suite.Err != nil {
+		fmt.Fprintf(w, "  %s %v\n", ErrStyle.Render("ERROR"), suite.Err)
+		return
+	}
+	for _, r := range suite.Results {
+		elapsed := helpStyle(fmt.Sprintf("(%s)", r.Duration.Round(time.Microsecond)))
+		if r.passed() {
+			fmt.Fprintf(w, "  %s %s %s\n", successStyle.Render("PASS"), r.Name, elapsed)
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s %s\n", ErrStyle.Render("FAIL"), r.Name, elapsed)
+		if r.Err != nil {
+			fmt.Fprintf(w, "       %v\n", r.Err)
+		}
+		for _, f := range r.Failures {
+			for _, line := range strings.Split(f, "\n") {
+				switch {
+				case strings.HasPrefix(line, "- "):
+					line = ErrStyle.Render(line)
+				case strings.HasPrefix(line, "+ "):
+					line = successStyle.Render(line)
+				}
+				fmt.Fprintf(w, "       %s\n", line)
+			}
+		}
+	}
+}
+
+// writeTestJUnit writes one JUnit test suite per test file.
+func writeTestJUnit(path string, suites []testSuiteResult) error {
+	report := junitTestSuites{Name: "contract test"}
+	for _, s := range suites {
+		suite := junitTestSuite{Name: s.File, Timestamp: time.Now().UTC().Format(time.RFC3339)}
+		if s.Err != nil {
+			suite.Errors = 1
+		}
+		for _, r := range s.Results {
+			tc := junitTestCase{Name: r.Name, ClassName: strings.TrimSuffix(filepath.ToSlash(s.File), filepath.Ext(s.File)), Time: r.Duration.Seconds()}
+			if !r.passed() {
+				var text []string
+				if r.Err != nil {
+					text = append(text, r.Err.Error())
+				}
+				text = append(text, r.Failures...)
+				tc.Failure = &junitFailure{Message: "expectation not met", Type: "contract.test", Text: strings.Join(text, "\n")}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Time += tc.Time
+			suite.Cases = append(suite.Cases, tc)
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Time += suite.Time
+		report.Suites = append(report.Suites, suite)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encodeJUnit(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encodeJUnit(w io.Writer, report junitTestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
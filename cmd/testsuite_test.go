@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func signEvent(validated bool) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "com.decombine.signature.sign",
+		"data": map[string]interface{}{"signature": map[string]interface{}{"validated": validated}},
+	}
+}
+
+// suiteContract is the contract created by init with the states its Draft transitions lead to.
+func suiteContract() *slc.Contract {
+	c := makeContract(&initContract{Name: "Test", SourceURL: "https://example.com/repo"})
+	c.State.States = append(c.State.States,
+		slc.State{Name: "In Process", Transitions: []slc.Transition{
+			{Name: "Completion", To: "Completed", On: "com.decombine.contract.completed"},
+		}},
+		slc.State{Name: "Completed"},
+		slc.State{Name: "Expired"},
+	)
+	return c
+}
+
+func TestRunTestCase(t *testing.T) {
+	expire := []deadline{{State: "Draft", After: "30d", Event: "com.decombine.contract.expirationReached"}}
+	tests := []struct {
+		name      string
+		deadlines []deadline
+		tc        testCase
+		failures  int
+		wantErr   bool
+	}{
+		{
+			name: "initial state",
+			tc: testCase{Expect: testExpectation{
+				State:       "Draft",
+				Transitions: &[]string{},
+				Actions:     &[]testAction{{Entry: "Draft"}},
+			}},
+		},
+		{
+			name: "conditions hold",
+			tc: testCase{
+				Events: []map[string]interface{}{signEvent(true)},
+				Expect: testExpectation{State: "In Process", Transitions: &[]string{"Signing"}},
+			},
+		},
+		{
+			name: "conditions fail",
+			tc: testCase{
+				Events: []map[string]interface{}{signEvent(false)},
+				Expect: testExpectation{State: "Draft", Transitions: &[]string{}},
+			},
+		},
+		{
+			name: "start skips the entry action",
+			tc: testCase{
+				Start:  "In Process",
+				Events: []map[string]interface{}{{"type": "com.decombine.contract.completed"}},
+				Expect: testExpectation{State: "Completed", Actions: &[]testAction{}},
+			},
+		},
+		{
+			name:      "advance fires deadlines",
+			deadlines: expire,
+			tc:        testCase{Advance: "31d", Expect: testExpectation{State: "Expired", Transitions: &[]string{"Expired"}}},
+		},
+		{
+			name:      "events before a deadline",
+			deadlines: expire,
+			tc: testCase{
+				Events:  []map[string]interface{}{withAt(signEvent(true), "10d")},
+				Advance: "31d",
+				Expect:  testExpectation{State: "In Process"},
+			},
+		},
+		{
+			name: "unexpected state and transitions",
+			tc: testCase{
+				Events: []map[string]interface{}{signEvent(true)},
+				Expect: testExpectation{State: "Draft", Transitions: &[]string{}},
+			},
+			failures: 2,
+		},
+		{
+			name:    "undefined start state",
+			tc:      testCase{Start: "Nowhere"},
+			wantErr: true,
+		},
+		{
+			name:    "event without a type",
+			tc:      testCase{Events: []map[string]interface{}{{"data": "x"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid advance",
+			tc:      testCase{Advance: "soon"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runTestCase(suiteContract(), tt.deadlines, tt.tc, nil)
+			if (r.Err != nil) != tt.wantErr {
+				t.Fatalf("runTestCase() error = %v, wantErr %v", r.Err, tt.wantErr)
+			}
+			if len(r.Failures) != tt.failures {
+				t.Errorf("runTestCase() failures = %q, want %d", r.Failures, tt.failures)
+			}
+		})
+	}
+}
+
+func withAt(e map[string]interface{}, at string) map[string]interface{} {
+	e["at"] = at
+	return e
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got []string
+		diff      string
+		equal     bool
+	}{
+		{name: "both empty", diff: "  (none)", equal: true},
+		{name: "equal", want: []string{"a", "b"}, got: []string{"a", "b"}, diff: "  a\n  b", equal: true},
+		{name: "missing", want: []string{"a", "b"}, got: []string{"a"}, diff: "  a\n- b"},
+		{name: "unexpected", want: []string{"a"}, got: []string{"a", "b"}, diff: "  a\n+ b"},
+		{name: "nothing happened", want: []string{"a"}, diff: "- a"},
+		{name: "reordered", want: []string{"a", "b"}, got: []string{"b", "a"}, diff: "+ b\n  a\n- b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, equal := diffLines(tt.want, tt.got)
+			if diff != tt.diff || equal != tt.equal {
+				t.Errorf("diffLines() = %q, %v, want %q, %v", diff, equal, tt.diff, tt.equal)
+			}
+		})
+	}
+}
+
+func TestFindTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("contract.yaml", "name: c\nstate:\n  initial: A\n")
+	write("tests/a.yaml", "contract: ../contract.yaml\ntests:\n  - name: a\n")
+	write("tests/b.yml", "# comment\ntests: []\n")
+	write("tests/notes.txt", "tests:\n")
+	write("workloads/kustomization.yaml", "resources: []\n")
+
+	files, err := findTestFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "tests/a.yaml"), filepath.Join(dir, "tests/b.yml")}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] {
+		t.Errorf("findTestFiles() = %q, want %q", files, want)
+	}
+
+	// Files named explicitly are run even without a tests key.
+	files, err = findTestFiles([]string{filepath.Join(dir, "contract.yaml")})
+	if err != nil || len(files) != 1 {
+		t.Errorf("findTestFiles() = %q, %v, want the named file", files, err)
+	}
+	if _, err := findTestFiles([]string{filepath.Join(dir, "workloads")}); err == nil {
+		t.Error("findTestFiles() of a directory without tests succeeded")
+	}
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(timelineCmd)
+	timelineCmd.Flags().StringP("fixture", "f", "", "A timeline fixture with deadlines and timed events, in YAML or JSON")
+	timelineCmd.Flags().StringArray("deadline", []string{}, "Declare a deadline as STATE=DURATION:EVENT, e.g. Draft=30d:com.decombine.contract.expirationReached")
+	timelineCmd.Flags().String("advance", "", "Advance the clock by this duration from the start, e.g. 30d. Units: y, w, d, h, m, s")
+	timelineCmd.Flags().String("start", "", "The start time of the clock in RFC 3339 (default now)")
+	timelineCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json")
+}
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline CONTRACT",
+	Short: "Walk a Smart Legal Contract through time",
+	Long: `Walk a Smart Legal Contract from its initial state on a virtual clock and show when time-driven transitions fire.
+
+Deadlines start when the contract enters their state and emit a CloudEvent if the contract is still in that state
+when they fall due. They are declared in a fixture, together with events sent by the parties at given times:
+
+  start: 2025-01-01T00:00:00Z
+  deadlines:
+    - name: Signature deadline
+      state: Draft
+      after: 30d
+      event: com.decombine.contract.expirationReached
+  events:
+    - at: 10d
+      type: com.decombine.signature.sign
+      data:
+        signature:
+          validated: true
+
+Events with an absolute "at" before the start of the clock are rejected. Without --advance the clock runs until no
+deadline is pending.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fixturePath, _ := cmd.Flags().GetString("fixture")
+		deadlineFlags, _ := cmd.Flags().GetStringArray("deadline")
+		advance, _ := cmd.Flags().GetString("advance")
+		startFlag, _ := cmd.Flags().GetString("start")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		var fixture timelineFixture
+		if fixturePath != "" {
+			if fixture, err = readTimelineFixture(fixturePath); err != nil {
+				return err
+			}
+		}
+		for _, f := range deadlineFlags {
+			d, err := parseDeadlineFlag(f)
+			if err != nil {
+				return err
+			}
+			fixture.Deadlines = append(fixture.Deadlines, d)
+		}
+
+		start := time.Now().UTC().Truncate(time.Second)
+		for _, s := range []string{fixture.Start, startFlag} {
+			if s == "" {
+				continue
+			}
+			if start, err = time.Parse(time.RFC3339, s); err != nil {
+				return fmt.Errorf("invalid start time: %w", err)
+			}
+		}
+
+		m, err := newMachine(c)
+		if err != nil {
+			return err
+		}
+		vc, err := newVirtualClock(m, start, fixture.Deadlines)
+		if err != nil {
+			return err
+		}
+		end := time.Time{}
+		if advance != "" {
+			d, err := parseDuration(advance)
+			if err != nil {
+				return err
+			}
+			end = start.Add(d)
+		}
+
+		trace, runErr := runTimeline(vc, fixture.Events, end)
+
+		out := cmd.OutOrStdout()
+		if output == "json" {
+			var pending []pendingDeadline
+			for _, t := range vc.armed {
+				pending = append(pending, pendingDeadline{Deadline: vc.deadlines[t.Deadline].String(), State: m.current, Due: t.Due})
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(struct {
+				Contract string            `json:"contract"`
+				Start    time.Time         `json:"start"`
+				Now      time.Time         `json:"now"`
+				Final    string            `json:"final"`
+				Trace    []timedStep       `json:"trace"`
+				Pending  []pendingDeadline `json:"pending,omitempty"`
+			}{c.Name, start, vc.now, m.current, trace, pending}); err != nil {
+				return err
+			}
+			return runErr
+		}
+		printTimeline(out, c.Name, vc, trace)
+		return runErr
+	},
+}
+
+// timelineFixture declares deadlines and the events the parties send, at offsets from the
+// start of the clock or at absolute times.
+type timelineFixture struct {
+	Start     string                   `yaml:"start,omitempty"`
+	Deadlines []deadline               `yaml:"deadlines"`
+	Events    []map[string]interface{} `yaml:"events"`
+}
+
+type pendingDeadline struct {
+	Deadline string    `json:"deadline"`
+	State    string    `json:"state"`
+	Due      time.Time `json:"due"`
+}
+
+func readTimelineFixture(path string) (timelineFixture, error) {
+	var f timelineFixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+	// goccy/go-yaml also reads JSON fixtures.
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("error decoding timeline fixture: %w", err)
+	}
+	return f, nil
+}
+
+// parseDeadlineFlag parses STATE=DURATION:EVENT.
+func parseDeadlineFlag(s string) (deadline, error) {
+	state, rest, ok := strings.Cut(s, "=")
+	after, event, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 {
+		return deadline{}, fmt.Errorf("invalid --deadline %q, expected STATE=DURATION:EVENT", s)
+	}
+	return deadline{State: state, After: after, Event: event}, nil
+}
+
+// timedEvent is an event sent by a party at a point on the clock.
+type timedEvent struct {
+	At    time.Time
+	Event cloudEvent
+}
+
+// scheduleEvents resolves the times of fixture events. Events without "at" are sent in order
+// at the time of the previous event.
+func scheduleEvents(vc *virtualClock, name string, events []map[string]interface{}) ([]timedEvent, error) {
+	var scheduled []timedEvent
+	at := vc.start
+	for i, fields := range events {
+		if v, ok := fields["at"]; ok {
+			t, err := vc.at(fmt.Sprint(v))
+			if err != nil {
+				return nil, fmt.Errorf("event %d: %w", i+1, err)
+			}
+			// The clock cannot go back, so the event would silently be delivered late.
+			if t.Before(vc.start) {
+				return nil, fmt.Errorf("%w: event %d at %s is before the start of the clock at %s", ErrInvalidEvent, i+1, t.Format(time.RFC3339), vc.start.Format(time.RFC3339))
+			}
+			at = t
+			fields = withoutKey(fields, "at")
+		}
+		e, err := testEvent(name, i, fields)
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, timedEvent{At: at, Event: e})
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool { return scheduled[i].At.Before(scheduled[j].At) })
+	return scheduled, nil
+}
+
+func withoutKey(m map[string]interface{}, key string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// runTimeline starts the contract and delivers the events in time order, firing deadlines as
+// the clock passes them. The clock stops at end, or when no deadline is pending if end is zero.
+func runTimeline(vc *virtualClock, events []map[string]interface{}, end time.Time) ([]timedStep, error) {
+	var trace []timedStep
+	first, err := vc.begin("")
+	if err != nil {
+		return nil, err
+	}
+	trace = append(trace, *first)
+
+	scheduled, err := scheduleEvents(vc, "timeline", events)
+	if err != nil {
+		return trace, err
+	}
+	for _, te := range scheduled {
+		if !end.IsZero() && te.At.After(end) {
+			break
+		}
+		steps, err := vc.advanceTo(te.At)
+		trace = append(trace, steps...)
+		if err != nil {
+			return trace, err
+		}
+		s, err := vc.deliver(te.Event)
+		if err != nil {
+			return trace, err
+		}
+		trace = append(trace, s)
+	}
+
+	if end.IsZero() {
+		for len(vc.armed) > 0 {
+			steps, err := vc.advanceTo(vc.armed[0].Due)
+			trace = append(trace, steps...)
+			if err != nil {
+				return trace, fmt.Errorf("%w; use --advance to stop the clock", err)
+			}
+		}
+		return trace, nil
+	}
+	steps, err := vc.advanceTo(end)
+	return append(trace, steps...), err
+}
+
+func printTimeline(w io.Writer, name string, vc *virtualClock, trace []timedStep) {
+	const layout = "2006-01-02 15:04"
+	fmt.Fprintf(w, "%s %s from %s\n\n", style.Render("Timeline of"), name, vc.start.Format(layout+" MST"))
+	for _, s := range trace {
+		when := fmt.Sprintf("  %s %s", s.At.Format(layout), helpStyle(fmt.Sprintf("%-8s", formatOffset(s.At.Sub(vc.start)))))
+		switch {
+		case s.Event == nil:
+			fmt.Fprintf(w, "%s enter %s\n", when, successStyle.Render(s.To))
+		case s.Deadline != "":
+			fmt.Fprintf(w, "%s %s %s\n", when, warnStyle.Render("⏰ "+s.Deadline), helpStyle(s.Event.Type))
+		default:
+			fmt.Fprintf(w, "%s %s\n", when, s.Event.Type)
+		}
+		if s.Event != nil {
+			if s.Fired {
+				fmt.Fprintf(w, "      %s %s %s\n", s.From, helpStyle("── "+s.Transition+" ─▶"), successStyle.Render(s.To))
+			} else {
+				fmt.Fprintf(w, "      %s %s\n", warnStyle.Render("ignored"), s.Reason)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s %s (%s) in %s\n", style.Render("Clock stopped at"), vc.now.Format(layout), formatOffset(vc.now.Sub(vc.start)), successStyle.Render(vc.m.current))
+	if len(vc.armed) > 0 {
+		fmt.Fprintln(w, style.Render("Pending deadlines"))
+		for _, t := range vc.armed {
+			fmt.Fprintf(w, "  %s %s %s\n", t.Due.Format(layout), helpStyle(fmt.Sprintf("%-8s", formatOffset(t.Due.Sub(vc.start)))), vc.deadlines[t.Deadline])
+		}
+	}
+}
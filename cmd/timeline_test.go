@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleEvents(t *testing.T) {
+	m, err := newMachine(makeContract(&initContract{Name: "Test", SourceURL: "https://example.com/repo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	vc, err := newVirtualClock(m, start, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := scheduleEvents(vc, "fixture", []map[string]interface{}{
+		{"type": "b", "at": "2d"},
+		{"type": "a", "at": "2025-01-02T00:00:00Z"},
+		{"type": "c"},
+	})
+	if err != nil {
+		t.Fatalf("scheduleEvents() error = %v", err)
+	}
+	// An event without "at" follows the one before it.
+	want := []time.Duration{24 * time.Hour, 24 * time.Hour, 48 * time.Hour}
+	order := []string{"a", "c", "b"}
+	if len(events) != len(order) {
+		t.Fatalf("scheduleEvents() = %d events, want %d", len(events), len(order))
+	}
+	for i, e := range events {
+		if e.Event.Type != order[i] || e.At.Sub(start) != want[i] {
+			t.Errorf("scheduleEvents()[%d] = %s at %s, want %s at +%s", i, e.Event.Type, e.At, order[i], want[i])
+		}
+	}
+
+	_, err = scheduleEvents(vc, "fixture", []map[string]interface{}{{"type": "a", "at": "2024-12-31T00:00:00Z"}})
+	if !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("scheduleEvents() of an event before the start error = %v, want %v", err, ErrInvalidEvent)
+	}
+}
@@ -1,13 +1,26 @@
 package cmd
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/decombine/slc"
 	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-yaml"
 	"github.com/spf13/cobra"
 )
 
@@ -19,13 +32,27 @@ var (
 
 var (
 	ErrSourceNotSupported = errors.New("source type not supported")
+	ErrFormatNotSupported = errors.New("contract format not supported")
+	ErrValidationFailed   = errors.New("one or more contracts failed validation")
+
+	ErrReportFormatNotSupported = errors.New("report format not supported")
 )
 
 func init() {
+	validate = validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their document key rather than the Go field name.
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		return fieldKey(f, "json")
+	})
+
 	rootCmd.AddCommand(validateCmd)
 	validateCmd.PersistentFlags().StringArrayVarP(&contracts, "contracts", "c", []string{}, "Validate multiple Smart Legal Contracts via chaining --contracts flags or piping to stdin.")
 	validateCmd.PersistentFlags().StringVar(&contract, "contract", "", "Validate a specific Smart Legal Contract. Stdin is also supported.")
 	validateCmd.Flags().BoolP("report", "r", false, "Generate a report of the validation results.")
+	validateCmd.Flags().String("report-format", "json", "The format of the validation report. Options: json, sarif, junit")
+	validateCmd.Flags().String("report-file", "", "Write the validation report to a file instead of stdout")
+	validateCmd.Flags().BoolVar(&resolveSources, "resolve-sources", false, "Check that the branches and paths of the contract source and policy exist in their Git repositories")
+	validateCmd.Flags().IntP("concurrency", "j", runtime.NumCPU(), "The maximum number of Smart Legal Contracts to validate in parallel")
 }
 
 var validateCmd = &cobra.Command{
@@ -34,80 +61,209 @@ var validateCmd = &cobra.Command{
 	Long: `Validate a Smart Legal Contract to ensure it is correctly formatted and adheres to the Contract schema.
 
 Validate will accept stdin for piping multiple Smart Legal Contracts to validate, individual Smart Legal Contracts using
-the --contract flag, or even repeated --contracts flags to validate multiple Smart Legal Contracts.`,
+the --contract flag, or even repeated --contracts flags to validate multiple Smart Legal Contracts.
+
+Use "-" to validate the contents piped to stdin, e.g. "cat contract.yaml | contract validate -". Streams of
+"---" separated YAML documents and newline-delimited JSON are validated document by document.
+
+Directories are searched recursively for contract.json, contract.yaml and contract.toml files, and glob patterns such
+as "contracts/**/contract.yaml" are expanded. Contracts are validated in parallel and reported as each one finishes.`,
+	SilenceUsage: true,
 
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-		//validate := validator.New(validator.WithRequiredStructEnabled())
 		single, _ := cmd.Flags().GetString("contract")
 		multi, _ := cmd.Flags().GetStringArray("contracts")
 
-		if single == "" && len(multi) == 0 {
-			if len(args) > 0 {
-				for _, c := range args {
-					s, err := getContractSource(c)
-					if err != nil {
-						fmt.Printf(ErrStyle.Render("error determining source type (file or url) of input %s: %v\n", c, err.Error()))
-					}
-					selectValidationStrategy(s, c)
-				}
-				return
-			}
-			//fmt.Printf(ErrStyle.Render("Error: no contracts to validate"))
+		run, err := newValidationRun(cmd)
+		if err != nil {
+			return err
 		}
 
+		var inputs []string
+		inputs = append(inputs, args...)
 		if single != "" {
-			s, err := getContractSource(single)
-			if err != nil {
-				fmt.Printf(ErrStyle.Render("error determining source type (file or url) of input %s: %v\n", single, err.Error()))
-			}
-			selectValidationStrategy(s, single)
-			return
+			inputs = append(inputs, single)
 		}
+		inputs = append(inputs, multi...)
 
-		if len(multi) > 0 {
-			for _, c := range multi {
-				s, err := getContractSource(c)
-				if err != nil {
-					fmt.Printf(ErrStyle.Render("error determining source type (file or url) of input %s: %v\n", c, err.Error()))
+		if len(inputs) == 0 {
+			// Piped input is either a list of contract locations or the
+			// contents of one or more Smart Legal Contracts.
+			data, err := readStdin(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if isPathList(data) {
+				for _, piece := range strings.Fields(string(data)) {
+					if strings.HasPrefix(piece, "-") {
+						// TODO: Handle flags
+						continue
+					}
+					inputs = append(inputs, piece)
 				}
-				selectValidationStrategy(s, c)
-				return
+			} else {
+				run.stdinData = data
+				inputs = append(inputs, "-")
 			}
 		}
 
-		if os.Stdin != nil {
-			fmt.Printf("Stdin is not nil\n")
-		}
-		// Set up a buffer so we can accept input from stdin
-		// for the scenario where multiple Smart Legal Contracts
-		// are piped to the validate command.
-		reader := bufio.NewReader(cmd.InOrStdin())
-		var inputs []string
-		text, _ := reader.ReadString('\n')
-		text = strings.Trim(text, "\r\n")
+		return run.validate(inputs)
+	},
+}
 
-		// Each Smart Legal Contract should be separated by a space.
-		cmdPieces := strings.Split(text, " ")
+// contractResult is the outcome of validating a single Smart Legal Contract.
+type contractResult struct {
+	Name        string       `json:"name"`
+	Format      string       `json:"format,omitempty"`
+	Valid       bool         `json:"valid"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+	Duration    float64      `json:"durationSeconds"`
 
-		for _, piece := range cmdPieces {
+	// data holds the raw contract so diagnostics can quote the source.
+	data []byte
+}
 
-			if strings.HasPrefix(piece, "-") {
-				// TODO: Handle flags
-				continue
-			}
+// validationRun collects the results of a validate invocation, printing each one as it
+// completes and writing the requested report at the end.
+type validationRun struct {
+	out          io.Writer
+	stdout       io.Writer
+	stdin        io.Reader
+	stdinData    []byte
+	concurrency  int
+	results      []contractResult
+	report       bool
+	reportFormat string
+	reportFile   string
+}
+
+func newValidationRun(cmd *cobra.Command) (*validationRun, error) {
+	run := &validationRun{out: cmd.OutOrStdout(), stdout: cmd.OutOrStdout(), stdin: cmd.InOrStdin()}
+	run.report, _ = cmd.Flags().GetBool("report")
+	run.reportFormat, _ = cmd.Flags().GetString("report-format")
+	run.reportFile, _ = cmd.Flags().GetString("report-file")
+	run.concurrency, _ = cmd.Flags().GetInt("concurrency")
+	if run.concurrency < 1 {
+		run.concurrency = 1
+	}
+	if cmd.Flags().Changed("report-format") || cmd.Flags().Changed("report-file") {
+		run.report = true
+	}
+	if !run.report {
+		return run, nil
+	}
+	if _, ok := reportWriters[run.reportFormat]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrReportFormatNotSupported, run.reportFormat)
+	}
+	// Keep stdout clean for the report when it is not written to a file.
+	if run.reportFile == "" || run.reportFile == "-" {
+		run.out = cmd.ErrOrStderr()
+	}
+	return run, nil
+}
 
-			inputs = append(inputs, piece)
-			s, err := getContractSource(piece)
+// validate expands the inputs into individual contracts and validates them with a bounded
+// pool of workers, streaming each result as it completes.
+func (r *validationRun) validate(inputs []string) error {
+	start := time.Now()
+	var paths []string
+	readStream := false
+	for _, in := range inputs {
+		if in == "-" {
+			readStream = true
+			continue
+		}
+		paths = append(paths, in)
+	}
+	if readStream {
+		if r.stdinData == nil {
+			data, err := readStdin(r.stdin)
 			if err != nil {
-				fmt.Printf(ErrStyle.Render("error determining source type (file or url) of input %s: %v\n", s, err.Error()))
-				return
+				return err
 			}
-			selectValidationStrategy(s, piece)
+			r.stdinData = data
+		}
+		for _, result := range validateStream(stdinName, r.stdinData) {
+			r.add(result)
+		}
+	}
+
+	targets, failures := expandInputs(paths)
+	for _, f := range failures {
+		r.add(f)
+	}
 
+	jobs := make(chan string)
+	results := make(chan contractResult)
+	var wg sync.WaitGroup
+	for i := 0; i < min(r.concurrency, len(targets)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				results <- validateInput(t)
+			}
+		}()
+	}
+	go func() {
+		for _, t := range targets {
+			jobs <- t
 		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	for result := range results {
+		r.add(result)
+	}
 
-	},
+	// Reports should not depend on the order in which workers finished.
+	sort.SliceStable(r.results, func(i, j int) bool {
+		return r.results[i].Name < r.results[j].Name
+	})
+	r.printSummary(time.Since(start))
+	return r.finish()
+}
+
+// printSummary writes the aggregated totals of the run.
+func (r *validationRun) printSummary(elapsed time.Duration) {
+	s := summarize(r.results)
+	status := successStyle.Render(fmt.Sprintf("%d valid", s.Valid))
+	if s.Invalid > 0 {
+		status += ", " + ErrStyle.Render(fmt.Sprintf("%d invalid", s.Invalid))
+	}
+	fmt.Fprintf(r.out, "\n%s %d contract(s) in %s: %s (%d error(s), %d warning(s))\n",
+		style.Render("Validated"), s.Total, elapsed.Round(time.Millisecond), status, s.Errors, s.Warnings)
+}
+
+func (r *validationRun) add(result contractResult) {
+	r.results = append(r.results, result)
+	printResult(r.out, result)
+}
+
+// finish writes the report, if requested, and converts the results into the command outcome.
+func (r *validationRun) finish() error {
+	if r.report {
+		if err := writeReport(r.stdout, r.results, r.reportFormat, r.reportFile); err != nil {
+			return fmt.Errorf("error writing report: %w", err)
+		}
+	}
+	for _, result := range r.results {
+		if !result.Valid {
+			return ErrValidationFailed
+		}
+	}
+	return nil
+}
+
+// validateInput determines the source of a single input and validates it.
+func validateInput(input string) contractResult {
+	s, err := getContractSource(input)
+	if err != nil {
+		return failedResult(input, "source/unknown", fmt.Errorf("error determining source type (file or url) of input: %w", err))
+	}
+	return selectValidationStrategy(s, input)
 }
 
 // getContractSource determines the source type of the Smart Legal Contract based on the input value.
@@ -117,7 +273,7 @@ func getContractSource(source string) (string, error) {
 	if source == "" {
 		return "", ErrSourceNotSupported
 	}
-	if strings.HasPrefix(source, "http") {
+	if isContractURL(source) {
 		return "url", nil
 	}
 	f, err := os.Open(source)
@@ -128,21 +284,217 @@ func getContractSource(source string) (string, error) {
 	return "fs", nil
 }
 
-func selectValidationStrategy(source, path string) {
+func selectValidationStrategy(source, path string) contractResult {
 	switch source {
 	case "fs":
-		validateFSContract(path)
+		return validateFSContract(path)
 	case "url":
-		validateURLContract(path)
+		return validateURLContract(path)
 	}
+	return failedResult(path, "source/unknown", ErrSourceNotSupported)
 }
 
 // validateFSContract validates a Smart Legal Contract from the filesystem.
-func validateFSContract(path string) {
-	fmt.Printf("Validating FS contract at %s\n", path)
+func validateFSContract(path string) contractResult {
+	data, format, err := readContract(path)
+	if err != nil {
+		return failedResult(path, "source/read", err)
+	}
+	return validateContractData(path, data, format)
 }
 
 // validateURLContract validates a Smart Legal Contract from a URL.
-func validateURLContract(url string) {
-	fmt.Printf("Validating URL contract at %s\n", url)
+func validateURLContract(url string) contractResult {
+	data, format, err := readContract(url)
+	if err != nil {
+		return failedResult(url, "source/read", err)
+	}
+	return validateContractData(url, data, format)
+}
+
+// validateContractData decodes and validates the raw contents of a Smart Legal Contract.
+func validateContractData(name string, data []byte, format string) contractResult {
+	start := time.Now()
+	diags := checkContract(name, data, format)
+	return contractResult{
+		Name:        name,
+		Format:      format,
+		Valid:       !hasErrors(diags),
+		Diagnostics: diags,
+		Duration:    time.Since(start).Seconds(),
+		data:        data,
+	}
+}
+
+// checkContract decodes a Smart Legal Contract and runs the schema and state machine checks,
+// returning diagnostics positioned in the source.
+func checkContract(name string, data []byte, format string) []diagnostic {
+	c, err := decodeContract(data, format)
+	if err != nil {
+		return []diagnostic{decodeDiagnostic(name, data, err)}
+	}
+	diags := validateContract(c)
+	diags = append(diags, analyzeStateMachine(c.State)...)
+	root := ""
+	if !isContractURL(name) {
+		// Paths in a contract read from stdin are resolved against the project of the working
+		// directory: the parent of a contracts directory or the nearest Git work tree.
+		root = projectRoot(name)
+	}
+	diags = append(diags, checkKubernetesActions(c, root)...)
+	if resolveSources {
+		diags = append(diags, checkSources(c, contractDir(name))...)
+	}
+	locateDiagnostics(name, data, format, diags)
+	return diags
+}
+
+// contractDir returns the directory that relative references in a contract are resolved
+// against: the contract's own directory for files and the working directory otherwise.
+func contractDir(name string) string {
+	if name == stdinName || isContractURL(name) {
+		return "."
+	}
+	return filepath.Dir(name)
+}
+
+// failedResult records a contract that could not be read or fetched.
+func failedResult(name, rule string, err error) contractResult {
+	return contractResult{
+		Name: name,
+		Diagnostics: []diagnostic{{
+			Severity: severityError,
+			Rule:     rule,
+			Message:  err.Error(),
+			Span:     sourceSpan{File: name},
+		}},
+	}
+}
+
+// printResult writes the status of a validated contract followed by its diagnostics.
+func printResult(w io.Writer, r contractResult) {
+	if r.Valid {
+		fmt.Fprintln(w, successStyle.Render("valid")+" "+r.Name)
+	} else {
+		fmt.Fprintln(w, ErrStyle.Render("invalid")+" "+r.Name)
+	}
+	printDiagnostics(w, r.data, r.Diagnostics)
+}
+
+// contractFormat determines the serialization format of a Smart Legal Contract. The file
+// extension is preferred and the content is inspected when the extension is not conclusive.
+func contractFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return "json"
+	}
+	var probe map[string]interface{}
+	if _, err := toml.Decode(string(data), &probe); err == nil {
+		return "toml"
+	}
+	return "yaml"
+}
+
+// readContract reads the raw contents of a Smart Legal Contract from a file or URL and
+// determines its format.
+func readContract(input string) ([]byte, string, error) {
+	if isContractURL(input) {
+		fetcher, err := defaultFetcher()
+		if err != nil {
+			return nil, "", err
+		}
+		fetched, err := fetcher.Fetch(context.Background(), input)
+		if err != nil {
+			return nil, "", err
+		}
+		return fetched.Data, fetched.Format, nil
+	}
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contractFormat(input, data), nil
+}
+
+// loadContract reads and decodes the Smart Legal Contract at input.
+func loadContract(input string) (*slc.Contract, error) {
+	data, format, err := readContract(input)
+	if err != nil {
+		return nil, err
+	}
+	return decodeContract(data, format)
+}
+
+// decodeContract decodes a Smart Legal Contract in the given format.
+func decodeContract(data []byte, format string) (*slc.Contract, error) {
+	var c slc.Contract
+	switch format {
+	case "json":
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("error decoding JSON contract: %w", err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("error decoding YAML contract: %w", err)
+		}
+	case "toml":
+		if _, err := toml.Decode(string(data), &c); err != nil {
+			return nil, fmt.Errorf("error decoding TOML contract: %w", err)
+		}
+	default:
+		return nil, ErrFormatNotSupported
+	}
+	return &c, nil
+}
+
+// validateContract checks a Smart Legal Contract against the struct tags of the slc schema
+// and returns every violation found.
+func validateContract(c *slc.Contract) []diagnostic {
+	err := validate.Struct(c)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []diagnostic{{Severity: severityError, Rule: "schema/invalid", Message: err.Error()}}
+	}
+	var diags []diagnostic
+	for _, fe := range fieldErrs {
+		diags = append(diags, diagnostic{
+			Severity: severityError,
+			Rule:     "schema/" + fe.Tag(),
+			Field:    strings.TrimPrefix(fe.StructNamespace(), "Contract."),
+			Message:  violationMessage(fe),
+		})
+	}
+	return diags
+}
+
+// violationMessage describes a validator field error in plain language.
+func violationMessage(fe validator.FieldError) string {
+	field := strconv.Quote(fe.Field())
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "url", "http_url":
+		return field + " must be a valid URL"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fe.Param())
+	case "min":
+		return fmt.Sprintf("%s must have a minimum of %s", field, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must have a maximum of %s", field, fe.Param())
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s failed the '%s=%s' check", field, fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("%s failed the '%s' check", field, fe.Tag())
 }
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrVerificationFailed = errors.New("verification failed")
+	ErrInvalidProperty    = errors.New("invalid property")
+)
+
+// propertyPattern matches assertions such as "Signed is always reachable from Draft".
+var propertyPattern = regexp.MustCompile(`(?i)^(.+?)\s+is\s+(?:(always|never|not)\s+)?(reachable|unreachable|absorbing)(?:\s+from\s+(.+))?$`)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringArrayP("assert", "a", []string{}, "Assert a property, e.g. \"Signed is always reachable from Draft\" or \"Terminated is absorbing\"")
+	verifyCmd.Flags().String("properties", "", "A file of properties to assert, one per line")
+	verifyCmd.Flags().StringArray("final", []string{}, "A state the contract is meant to end in (default every state without transitions)")
+	verifyCmd.Flags().Int("depth", 100, "The maximum number of events to explore from a state")
+	verifyCmd.Flags().StringP("output", "o", "text", "The output format. Options: text, json")
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify CONTRACT",
+	Short: "Model check the state machine of a Smart Legal Contract",
+	Long: `Explore every state a Smart Legal Contract can reach and report logical flaws in how the agreement can move.
+
+The events of the contract are the values of its transitions' "on" fields. Conditions are not evaluated, so a
+transition with conditions may or may not fire. verify reports:
+
+  deadlock   a state the contract can never leave that is not a final state
+  live-lock  states that form a cycle with no exit
+  no-final   a state from which no final state can be reached
+  shadowed   a transition that never fires because an earlier one handles its event first
+
+Properties are asserted with --assert or read from a file, one per line:
+
+  Signed is reachable [from Draft]          some sequence of events leads from Draft to Signed
+  Signed is always reachable [from Draft]   Signed can be reached from every state reachable from Draft
+  Voided is unreachable [from Draft]        no sequence of events leads from Draft to Voided
+  Terminated is absorbing                   no transition leaves Terminated
+
+Without "from" a property starts at the initial state.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asserts, _ := cmd.Flags().GetStringArray("assert")
+		propertiesFile, _ := cmd.Flags().GetString("properties")
+		finals, _ := cmd.Flags().GetStringArray("final")
+		depth, _ := cmd.Flags().GetInt("depth")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("%w: %s", ErrOutputNotSupported, output)
+		}
+		if depth < 1 {
+			return fmt.Errorf("--depth must be at least 1")
+		}
+		if propertiesFile != "" {
+			lines, err := readProperties(propertiesFile)
+			if err != nil {
+				return err
+			}
+			asserts = append(lines, asserts...)
+		}
+
+		c, err := loadContract(args[0])
+		if err != nil {
+			return err
+		}
+		sp, findings, err := newStateSpace(c, finals, depth)
+		if err != nil {
+			return err
+		}
+		var props []property
+		for _, a := range asserts {
+			p, err := parseProperty(a, sp)
+			if err != nil {
+				return err
+			}
+			props = append(props, p)
+		}
+
+		explored, more := sp.check()
+		report := verifyReport{
+			Contract: c.Name,
+			States:   len(sp.index),
+			Explored: explored,
+			Events:   sp.alphabet,
+			Findings: append(more, findings...),
+		}
+		for _, p := range props {
+			report.Properties = append(report.Properties, sp.assert(p))
+		}
+
+		out := cmd.OutOrStdout()
+		if output == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			printVerifyReport(out, report)
+		}
+		if !report.passed() {
+			return ErrVerificationFailed
+		}
+		return nil
+	},
+}
+
+type verifyReport struct {
+	Contract   string           `json:"contract"`
+	States     int              `json:"states"`
+	Explored   int              `json:"explored"`
+	Events     []string         `json:"events"`
+	Findings   []verifyFinding  `json:"findings"`
+	Properties []propertyResult `json:"properties,omitempty"`
+}
+
+func (r verifyReport) passed() bool {
+	for _, f := range r.Findings {
+		if f.Severity == severityError {
+			return false
+		}
+	}
+	for _, p := range r.Properties {
+		if !p.Holds {
+			return false
+		}
+	}
+	return true
+}
+
+// property is an assertion about the state space.
+type property struct {
+	Text  string
+	Kind  string
+	State int
+	From  int
+}
+
+type propertyResult struct {
+	Property string `json:"property"`
+	Holds    bool   `json:"holds"`
+	// Message explains why the property does not hold; Path is the witness or counterexample.
+	Message string      `json:"message,omitempty"`
+	Path    []spaceEdge `json:"path,omitempty"`
+}
+
+func readProperties(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(text string, sp *stateSpace) (property, error) {
+	m := propertyPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return property{}, fmt.Errorf("%w: %q", ErrInvalidProperty, text)
+	}
+	modifier, verb := strings.ToLower(m[2]), strings.ToLower(m[3])
+	p := property{Text: text, Kind: verb}
+	switch {
+	case verb == "reachable" && modifier == "always":
+		p.Kind = "always-reachable"
+	case verb == "reachable" && modifier != "":
+		p.Kind = "unreachable"
+	case verb != "reachable" && modifier != "":
+		return property{}, fmt.Errorf("%w: %q: %q cannot be combined with %q", ErrInvalidProperty, text, modifier, verb)
+	case verb == "absorbing" && m[4] != "":
+		return property{}, fmt.Errorf("%w: %q: absorbing does not take a starting state", ErrInvalidProperty, text)
+	}
+
+	resolve := func(name string) (int, error) {
+		name = strings.Trim(strings.TrimSpace(name), `"'`)
+		i, ok := sp.index[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: %q: %w: %q", ErrInvalidProperty, text, ErrUndefinedState, name)
+		}
+		return i, nil
+	}
+	var err error
+	if p.State, err = resolve(m[1]); err != nil {
+		return property{}, err
+	}
+	from := sp.contract.State.Initial
+	if m[4] != "" {
+		from = m[4]
+	}
+	if p.From, err = resolve(from); err != nil {
+		return property{}, err
+	}
+	return p, nil
+}
+
+// assert checks a property against the state space.
+func (sp *stateSpace) assert(p property) propertyResult {
+	r := propertyResult{Property: p.Text, Holds: true}
+	target, from := sp.name(p.State), sp.name(p.From)
+	switch p.Kind {
+	case "reachable", "unreachable":
+		parents, _ := sp.search(p.From)
+		_, reached := parents[p.State]
+		if reached {
+			r.Path = spacePath(parents, p.State)
+		}
+		r.Holds = reached == (p.Kind == "reachable")
+		if !r.Holds && reached {
+			r.Message = fmt.Sprintf("%q can be reached from %q", target, from)
+		} else if !r.Holds {
+			r.Message = fmt.Sprintf("%q cannot be reached from %q", target, from)
+		}
+	case "always-reachable":
+		parents, _ := sp.search(p.From)
+		for _, i := range sortedStates(parents) {
+			if next, _ := sp.search(i); next[p.State] != nil || i == p.State {
+				continue
+			}
+			r.Holds = false
+			r.Path = spacePath(parents, i)
+			r.Message = fmt.Sprintf("once the contract reaches %q, %q can no longer be reached", sp.name(i), target)
+			break
+		}
+	case "absorbing":
+		for _, e := range sp.edges[p.State] {
+			if e.to != p.State {
+				r.Holds = false
+				r.Path = []spaceEdge{e}
+				r.Message = fmt.Sprintf("transition %s leaves %q on %s", e.Transition, target, e.Event)
+				break
+			}
+		}
+	}
+	return r
+}
+
+func printVerifyReport(w io.Writer, r verifyReport) {
+	fmt.Fprintf(w, "%s %s: %d of %d states reachable, %d events\n", style.Render("Verified"), r.Contract, r.Explored, r.States, len(r.Events))
+	for _, e := range r.Events {
+		fmt.Fprintf(w, "  %s\n", helpStyle(e))
+	}
+
+	fmt.Fprintln(w)
+	if len(r.Findings) == 0 {
+		fmt.Fprintf(w, "%s no deadlocks, live-locks or states without a way to finish\n", successStyle.Render("✓"))
+	}
+	for _, f := range r.Findings {
+		sev := severityStyle(f.Severity)
+		fmt.Fprintf(w, "%s[%s]: %s\n", sev.Render(string(f.Severity)), f.Kind, f.Message)
+		printSpacePath(w, f.Path)
+	}
+
+	if len(r.Properties) > 0 {
+		fmt.Fprintln(w, "\n"+style.Render("Properties"))
+	}
+	for _, p := range r.Properties {
+		if p.Holds {
+			fmt.Fprintf(w, "  %s %s\n", successStyle.Render("PASS"), p.Property)
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s\n", ErrStyle.Render("FAIL"), p.Property)
+		fmt.Fprintf(w, "       %s\n", p.Message)
+		printSpacePath(w, p.Path)
+	}
+}
+
+func printSpacePath(w io.Writer, p []spaceEdge) {
+	if len(p) == 0 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(p[0].From)
+	for _, e := range p {
+		fmt.Fprintf(&b, " %s %s", helpStyle("── "+e.Transition+" ─▶"), e.To)
+	}
+	fmt.Fprintf(w, "       %s %s\n", gutterStyle.Render("path:"), b.String())
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// cliVersion is the released version of the Contract CLI.
+const cliVersion = "v0.2.3-alpha"
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
@@ -15,6 +18,6 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version number of Contract",
 	Long:  `All software has versions. This is Contract's`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(style.Render("Contract v0.2.3-alpha"))
+		fmt.Println(style.Render("Contract " + cliVersion))
 	},
 }
@@ -16,6 +16,7 @@ require (
 	github.com/spf13/viper v1.20.1
 	github.com/zitadel/oidc/v3 v3.37.0
 	golang.org/x/text v0.24.0
+	k8s.io/apimachinery v0.32.4
 )
 
 require (
@@ -127,7 +128,6 @@ require (
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	k8s.io/api v0.32.4 // indirect
 	k8s.io/apiextensions-apiserver v0.32.4 // indirect
-	k8s.io/apimachinery v0.32.4 // indirect
 	k8s.io/client-go v0.32.4 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20250318190949-c8a335a9a2ff // indirect
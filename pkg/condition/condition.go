@@ -0,0 +1,207 @@
+// Package condition evaluates the conditions of Smart Legal Contract transitions against
+// CloudEvents.
+//
+// A condition's Name is a dotted path into the event, such as data.signature.validated, and
+// its Value is the expected value written as a string. The value at the path is compared
+// according to its type: booleans match only the literals "true" and "false", numbers are
+// parsed from Value so "1.50" matches 1.5, and strings must match exactly.
+package condition
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/decombine/slc"
+)
+
+var (
+	// ErrPathNotFound is returned when a condition's path does not exist in the event.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrIncomparable is returned when the value at a path cannot be compared with the
+	// condition's value.
+	ErrIncomparable = errors.New("values cannot be compared")
+)
+
+// PathError describes where the resolution of a dotted path failed.
+type PathError struct {
+	Path string
+	// Segment is the key or index that could not be resolved.
+	Segment string
+	// Parent is the portion of the path that was resolved before the failure.
+	Parent string
+	Reason string
+}
+
+func (e *PathError) Error() string {
+	if e.Parent == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s in %s", e.Path, e.Reason, e.Parent)
+}
+
+func (e *PathError) Unwrap() error {
+	return ErrPathNotFound
+}
+
+// Result is the outcome of evaluating a single condition.
+type Result struct {
+	Condition slc.Condition `json:"condition"`
+	// Actual is the value found at the condition's path, if any.
+	Actual interface{} `json:"actual,omitempty"`
+	Holds  bool        `json:"holds"`
+	// Err explains why the condition could not be evaluated, e.g. a missing path.
+	Err error `json:"-"`
+}
+
+// Reason describes a condition that does not hold.
+func (r Result) Reason() string {
+	switch {
+	case r.Holds:
+		return ""
+	case r.Err != nil:
+		return r.Err.Error()
+	}
+	return fmt.Sprintf("%s is %s, want %q", r.Condition.Name, Format(r.Actual), r.Condition.Value)
+}
+
+// Lookup resolves a dotted path in a decoded JSON document. Numeric segments index into
+// arrays, e.g. data.signers.0.email.
+func Lookup(doc interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return nil, &PathError{Path: path, Reason: "empty path"}
+	}
+	v := doc
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		parent := strings.Join(segments[:i], ".")
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[seg]
+			if !ok {
+				return nil, &PathError{Path: path, Segment: seg, Parent: parent, Reason: fmt.Sprintf("%q is not set", seg)}
+			}
+			v = next
+		case []interface{}:
+			n, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, &PathError{Path: path, Segment: seg, Parent: parent, Reason: fmt.Sprintf("%q is not an index of an array", seg)}
+			}
+			if n < 0 || n >= len(node) {
+				return nil, &PathError{Path: path, Segment: seg, Parent: parent, Reason: fmt.Sprintf("index %d is out of range for %d element(s)", n, len(node))}
+			}
+			v = node[n]
+		case nil:
+			return nil, &PathError{Path: path, Segment: seg, Parent: parent, Reason: fmt.Sprintf("cannot read %q of null", seg)}
+		default:
+			return nil, &PathError{Path: path, Segment: seg, Parent: parent, Reason: fmt.Sprintf("cannot read %q of %s", seg, Format(node))}
+		}
+	}
+	return v, nil
+}
+
+// Compare reports whether actual equals the expected value written as a string.
+func Compare(actual interface{}, want string) (bool, error) {
+	switch v := actual.(type) {
+	case nil:
+		return want == "" || want == "null", nil
+	case bool:
+		// Only the JSON literals are accepted, not the "1", "T" or "FALSE" of strconv.ParseBool.
+		if want != "true" && want != "false" {
+			return false, fmt.Errorf("%w: boolean %t and %q", ErrIncomparable, v, want)
+		}
+		return v == (want == "true"), nil
+	case string:
+		if v == want {
+			return true, nil
+		}
+		// Events sometimes carry numbers as strings.
+		if a, ok := parseNumber(v); ok {
+			if b, ok := parseNumber(want); ok {
+				return a.Cmp(b) == 0, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}, []interface{}:
+		return false, fmt.Errorf("%w: %s and %q", ErrIncomparable, Format(v), want)
+	}
+
+	a, ok := parseNumber(fmt.Sprint(actual))
+	if !ok {
+		return false, fmt.Errorf("%w: %v and %q", ErrIncomparable, actual, want)
+	}
+	b, ok := parseNumber(want)
+	if !ok {
+		return false, fmt.Errorf("%w: number %v and %q", ErrIncomparable, actual, want)
+	}
+	return a.Cmp(b) == 0, nil
+}
+
+// parseNumber parses s exactly so large integers and decimals compare without rounding.
+func parseNumber(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(s)
+	return r, ok
+}
+
+// Evaluate resolves a condition's path in the event document and compares the value.
+func Evaluate(c slc.Condition, doc interface{}) Result {
+	r := Result{Condition: c}
+	v, err := Lookup(doc, c.Name)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Actual = v
+	r.Holds, r.Err = Compare(v, c.Value)
+	if r.Err != nil {
+		r.Err = fmt.Errorf("%s: %w", c.Name, r.Err)
+	}
+	return r
+}
+
+// EvaluateAll evaluates every condition and reports whether all of them hold. A transition
+// without conditions always holds.
+func EvaluateAll(conditions []slc.Condition, doc interface{}) ([]Result, bool) {
+	results := make([]Result, 0, len(conditions))
+	holds := true
+	for _, c := range conditions {
+		r := Evaluate(c, doc)
+		holds = holds && r.Holds
+		results = append(results, r)
+	}
+	return results, holds
+}
+
+// Decode decodes a JSON document for evaluation, keeping numbers exact.
+func Decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Format renders a value found in an event for messages.
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	}
+	return fmt.Sprint(v)
+}
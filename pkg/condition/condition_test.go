@@ -0,0 +1,158 @@
+package condition
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/decombine/slc"
+)
+
+func mustDecode(t *testing.T, s string) interface{} {
+	t.Helper()
+	doc, err := Decode([]byte(s))
+	if err != nil {
+		t.Fatalf("Decode(%s) error = %v", s, err)
+	}
+	return doc
+}
+
+func TestLookup(t *testing.T) {
+	doc := `{"data": {"signature": {"validated": true}, "signers": [{"email": "a@example.com"}], "none": null, "n": 1}}`
+	tests := []struct {
+		path    string
+		want    interface{}
+		segment string
+		wantErr bool
+	}{
+		{path: "data.signature.validated", want: true},
+		{path: "data.signers.0.email", want: "a@example.com"},
+		{path: "data.none", want: nil},
+		{path: "data.missing", segment: "missing", wantErr: true},
+		{path: "data.signers.x", segment: "x", wantErr: true},
+		{path: "data.signers.1", segment: "1", wantErr: true},
+		{path: "data.signers.-1", segment: "-1", wantErr: true},
+		{path: "data.none.x", segment: "x", wantErr: true},
+		{path: "data.n.x", segment: "x", wantErr: true},
+		{path: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := Lookup(mustDecode(t, doc), tt.path)
+			if tt.wantErr {
+				var pe *PathError
+				if !errors.As(err, &pe) || !errors.Is(err, ErrPathNotFound) {
+					t.Fatalf("Lookup() error = %v, want a PathError", err)
+				}
+				if pe.Segment != tt.segment {
+					t.Errorf("Lookup() segment = %q, want %q", pe.Segment, tt.segment)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		actual  interface{}
+		want    string
+		holds   bool
+		wantErr bool
+	}{
+		{name: "true", actual: true, want: "true", holds: true},
+		{name: "false", actual: false, want: "false", holds: true},
+		{name: "boolean mismatch", actual: true, want: "false"},
+		{name: "boolean shorthand", actual: true, want: "1", wantErr: true},
+		{name: "boolean capitals", actual: false, want: "FALSE", wantErr: true},
+		{name: "boolean spaces", actual: true, want: " true", wantErr: true},
+		{name: "string", actual: "signed", want: "signed", holds: true},
+		{name: "string mismatch", actual: "signed", want: "Signed"},
+		{name: "numeric string", actual: "1.50", want: "1.5", holds: true},
+		{name: "boolean string", actual: "true", want: "true", holds: true},
+		{name: "boolean string shorthand", actual: "1", want: "true"},
+		{name: "number", actual: json.Number("1.50"), want: "1.5", holds: true},
+		{name: "large integer", actual: json.Number("9007199254740993"), want: "9007199254740992"},
+		{name: "float", actual: 2.0, want: "2", holds: true},
+		{name: "number and text", actual: json.Number("1"), want: "one", wantErr: true},
+		{name: "null", actual: nil, want: "null", holds: true},
+		{name: "null and empty", actual: nil, want: "", holds: true},
+		{name: "null mismatch", actual: nil, want: "x"},
+		{name: "object", actual: map[string]interface{}{}, want: "{}", wantErr: true},
+		{name: "array", actual: []interface{}{}, want: "[]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holds, err := Compare(tt.actual, tt.want)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrIncomparable) {
+				t.Errorf("Compare() error = %v, want %v", err, ErrIncomparable)
+			}
+			if holds != tt.holds {
+				t.Errorf("Compare() = %v, want %v", holds, tt.holds)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	doc := `{"data": {"signature": {"validated": true}, "amount": 100}}`
+	tests := []struct {
+		name      string
+		condition slc.Condition
+		holds     bool
+		reason    string
+	}{
+		{
+			name:      "holds",
+			condition: slc.Condition{Name: "data.signature.validated", Value: "true"},
+			holds:     true,
+		},
+		{
+			name:      "does not hold",
+			condition: slc.Condition{Name: "data.amount", Value: "200"},
+			reason:    `data.amount is 100, want "200"`,
+		},
+		{
+			name:      "missing path",
+			condition: slc.Condition{Name: "data.signature.date", Value: "x"},
+			reason:    `data.signature.date: "date" is not set in data.signature`,
+		},
+		{
+			name:      "incomparable",
+			condition: slc.Condition{Name: "data.signature", Value: "x"},
+			reason:    `data.signature: values cannot be compared: an object and "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Evaluate(tt.condition, mustDecode(t, doc))
+			if r.Holds != tt.holds {
+				t.Errorf("Evaluate().Holds = %v, want %v", r.Holds, tt.holds)
+			}
+			if got := r.Reason(); got != tt.reason {
+				t.Errorf("Evaluate().Reason() = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateAll(t *testing.T) {
+	doc := mustDecode(t, `{"data": {"a": 1, "b": "x"}}`)
+	if _, holds := EvaluateAll(nil, doc); !holds {
+		t.Error("EvaluateAll() without conditions does not hold")
+	}
+	results, holds := EvaluateAll([]slc.Condition{{Name: "data.a", Value: "1"}, {Name: "data.b", Value: "y"}}, doc)
+	if holds || len(results) != 2 || !results[0].Holds || results[1].Holds {
+		t.Errorf("EvaluateAll() = %+v, %v", results, holds)
+	}
+}